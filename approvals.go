@@ -0,0 +1,148 @@
+package clusterstate
+
+import "math"
+
+// ApprovalPolicy defines which operators must approve (build-on) a mutation
+// before it is applied to the cluster.
+type ApprovalPolicy interface {
+	// Approved returns true if the operators in approvedBy satisfy the policy
+	// given the cluster state before the mutation is applied.
+	Approved(approvedBy map[PublicKey]bool, cluster Cluster) bool
+}
+
+// Approvals is the basic approval policy requiring none, a quorum or all operators.
+type Approvals int
+
+const (
+	ApprovalsNone Approvals = iota
+	ApprovalsQuorum
+	ApprovalsAll
+)
+
+func (a Approvals) Approved(approvedBy map[PublicKey]bool, cluster Cluster) bool {
+	if a == ApprovalsNone {
+		return true
+	}
+
+	count := countApproved(approvedBy, cluster)
+
+	if a == ApprovalsQuorum {
+		q := int(math.Ceil(float64(2*len(cluster.Operators)) / 3))
+		return count >= q
+	}
+
+	return count == len(cluster.Operators)
+}
+
+// ApprovalsThreshold requires at least M of the N cluster operators to approve.
+type ApprovalsThreshold struct {
+	M int
+}
+
+func (t ApprovalsThreshold) Approved(approvedBy map[PublicKey]bool, cluster Cluster) bool {
+	return countApproved(approvedBy, cluster) >= t.M
+}
+
+// ApprovalsWeighted requires the sum of the weights of approving operators to reach the threshold.
+// Operators not present in Weights have a weight of zero.
+type ApprovalsWeighted struct {
+	Weights   map[PublicKey]int
+	Threshold int
+}
+
+func (w ApprovalsWeighted) Approved(approvedBy map[PublicKey]bool, cluster Cluster) bool {
+	var sum int
+	for _, op := range cluster.Operators {
+		if approvedBy[op.PublicKey] {
+			sum += w.Weights[op.PublicKey]
+		}
+	}
+
+	return sum >= w.Threshold
+}
+
+// ApprovalsRequired requires all the specified operators to approve, effectively giving each of them a veto.
+// Required operators that are not part of the cluster can never approve.
+type ApprovalsRequired struct {
+	Operators []PublicKey
+	// Creator additionally requires the cluster creator to approve, even if not an operator.
+	Creator bool
+}
+
+func (r ApprovalsRequired) Approved(approvedBy map[PublicKey]bool, cluster Cluster) bool {
+	if r.Creator && !approvedBy[cluster.Creator] {
+		return false
+	}
+
+	for _, key := range r.Operators {
+		if !approvedBy[key] || !isOperator(key, cluster) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ApprovalsAllOf requires all the policies to approve.
+type ApprovalsAllOf []ApprovalPolicy
+
+func (a ApprovalsAllOf) Approved(approvedBy map[PublicKey]bool, cluster Cluster) bool {
+	for _, policy := range a {
+		if !Approved(policy, approvedBy, cluster) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ApprovalsAnyOf requires at least one of the policies to approve.
+type ApprovalsAnyOf []ApprovalPolicy
+
+func (a ApprovalsAnyOf) Approved(approvedBy map[PublicKey]bool, cluster Cluster) bool {
+	for _, policy := range a {
+		if Approved(policy, approvedBy, cluster) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Approved returns true if the policy is satisfied by the operators in approvedBy.
+// A nil policy is equivalent to ApprovalsNone.
+func Approved(policy ApprovalPolicy, approvedBy map[PublicKey]bool, cluster Cluster) bool {
+	if policy == nil {
+		return true
+	}
+
+	return policy.Approved(approvedBy, cluster)
+}
+
+// isNoApprovals returns true if the policy doesn't require any approvals, treating nil as ApprovalsNone.
+func isNoApprovals(policy ApprovalPolicy) bool {
+	return policy == nil || policy == ApprovalsNone
+}
+
+// countApproved returns the number of cluster operators that approved.
+func countApproved(approvedBy map[PublicKey]bool, cluster Cluster) int {
+	var count int
+	for _, op := range cluster.Operators {
+		if approvedBy[op.PublicKey] {
+			count++
+		}
+	}
+
+	return count
+}
+
+// isOperator returns true if the key is a cluster operator.
+func isOperator(key PublicKey, cluster Cluster) bool {
+	for _, op := range cluster.Operators {
+		if op.PublicKey == key {
+			return true
+		}
+	}
+
+	return false
+}
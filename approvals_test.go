@@ -0,0 +1,84 @@
+package clusterstate
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApprovalPolicies(t *testing.T) {
+	cluster := Cluster{Creator: "creator"}
+	for _, op := range newOperators(4) {
+		cluster.Operators = append(cluster.Operators, Operator{PublicKey: op})
+	}
+
+	approvedBy := func(keys ...PublicKey) map[PublicKey]bool {
+		resp := make(map[PublicKey]bool)
+		for _, key := range keys {
+			resp[key] = true
+		}
+
+		return resp
+	}
+
+	tests := []struct {
+		name       string
+		policy     ApprovalPolicy
+		approvedBy map[PublicKey]bool
+		approved   bool
+	}{
+		{"nil", nil, approvedBy(), true},
+		{"none", ApprovalsNone, approvedBy(), true},
+		{"quorum met", ApprovalsQuorum, approvedBy("operator-0", "operator-1", "operator-2"), true},
+		{"quorum not met", ApprovalsQuorum, approvedBy("operator-0", "operator-1"), false},
+		{"quorum ignores non-operators", ApprovalsQuorum, approvedBy("operator-0", "operator-1", "other"), false},
+		{"all met", ApprovalsAll, approvedBy("operator-0", "operator-1", "operator-2", "operator-3"), true},
+		{"all not met", ApprovalsAll, approvedBy("operator-0", "operator-1", "operator-2"), false},
+		{"threshold met", ApprovalsThreshold{M: 2}, approvedBy("operator-0", "operator-3"), true},
+		{"threshold not met", ApprovalsThreshold{M: 2}, approvedBy("operator-0"), false},
+		{"weighted met", ApprovalsWeighted{Weights: map[PublicKey]int{"operator-0": 3}, Threshold: 3}, approvedBy("operator-0"), true},
+		{"weighted not met", ApprovalsWeighted{Weights: map[PublicKey]int{"operator-0": 3}, Threshold: 3}, approvedBy("operator-1", "operator-2"), false},
+		{"required met", ApprovalsRequired{Operators: []PublicKey{"operator-1"}, Creator: true}, approvedBy("operator-1", "creator"), true},
+		{"required missing creator", ApprovalsRequired{Operators: []PublicKey{"operator-1"}, Creator: true}, approvedBy("operator-1"), false},
+		{"required non-operator", ApprovalsRequired{Operators: []PublicKey{"other"}}, approvedBy("other"), false},
+		{"any of", ApprovalsAnyOf{ApprovalsAll, ApprovalsThreshold{M: 1}}, approvedBy("operator-2"), true},
+		{"any of none met", ApprovalsAnyOf{ApprovalsAll, ApprovalsThreshold{M: 2}}, approvedBy("operator-2"), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Approved(test.policy, test.approvedBy, cluster); got != test.approved {
+				t.Fatalf("expected approved=%v, got %v", test.approved, got)
+			}
+		})
+	}
+}
+
+func TestNilApprovalsSingleParent(t *testing.T) {
+	const typ MutationType = "test/nil_approvals/1.0.0"
+	err := RegisterMutationType(typ, TypeDef{
+		ParentTypes: []MutationType{TypeCreateCluster, TypeOperatorENR},
+		AppendFunc: func(_ SignedMutation, c Cluster) (Cluster, error) {
+			return c, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { delete(typeDef, typ) })
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(2)
+	create := NewSignedMutation(clock, ops[0], TypeCreateCluster, newCreateCluster(ops))
+	enr := NewSignedMutation(clock, ops[1], TypeOperatorENR, OperatorENR{ENR: "enr"}, create)
+	state := State{create, enr}
+
+	// A nil policy is equivalent to ApprovalsNone, so the mutation may only have a single parent.
+	err = ValidateAdd(state, NewSignedMutation(clock, ops[0], typ, nil, create, enr))
+	if err == nil || !strings.Contains(err.Error(), "single parent") {
+		t.Fatalf("expected single parent error, got %v", err)
+	}
+
+	if err := ValidateAdd(state, NewSignedMutation(clock, ops[0], typ, nil, enr)); err != nil {
+		t.Fatal(err)
+	}
+}
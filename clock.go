@@ -0,0 +1,49 @@
+package clusterstate
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time, it is used to timestamp mutations.
+type Clock interface {
+	Now() time.Time
+}
+
+// NewSystemClock returns a clock using the system time.
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewFakeClock returns a deterministic clock starting at the provided time.
+// It only advances when Advance is called, which makes mutation hashes reproducible.
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// FakeClock is a deterministic clock for tests and simulations.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Advance moves the clock forward by the duration.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
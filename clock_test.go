@@ -0,0 +1,59 @@
+package clusterstate
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Unix(100, 0)
+	clock := NewFakeClock(start)
+	if !clock.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, clock.Now())
+	}
+
+	clock.Advance(time.Second)
+	if want := start.Add(time.Second); !clock.Now().Equal(want) {
+		t.Fatalf("expected %v, got %v", want, clock.Now())
+	}
+}
+
+func TestNewSignedMutationReproducible(t *testing.T) {
+	ops := newOperators(2)
+	newCreate := func(source PublicKey) SignedMutation {
+		return NewSignedMutation(NewFakeClock(time.Unix(0, 0)), source, TypeCreateCluster, newCreateCluster(ops))
+	}
+
+	if newCreate("creator").Hash != newCreate("creator").Hash {
+		t.Fatal("expected identical hashes for identical clocks")
+	} else if newCreate("creator").Hash == newCreate("other").Hash {
+		t.Fatal("expected different hashes for different sources")
+	}
+}
+
+func TestValidateAddWithClock(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1000, 0))
+	ops := newOperators(2)
+	create := NewSignedMutation(clock, "creator", TypeCreateCluster, newCreateCluster(ops))
+	state := State{create}
+
+	clock.Advance(time.Second)
+	if err := ValidateAddWithClock(clock, state, NewSignedMutation(clock, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr"}, create)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Mutations from a clock ahead by more than the drift are rejected.
+	ahead := NewFakeClock(clock.Now().Add(MaxClockDrift + time.Second))
+	err := ValidateAddWithClock(clock, state, NewSignedMutation(ahead, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr"}, create))
+	if err == nil || !strings.Contains(err.Error(), "future") {
+		t.Fatalf("expected future timestamp error, got %v", err)
+	}
+
+	// Mutations timestamped before their parents are rejected.
+	behind := NewFakeClock(time.Unix(0, 0))
+	err = ValidateAddWithClock(clock, state, NewSignedMutation(behind, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr"}, create))
+	if err == nil || !strings.Contains(err.Error(), "before parent") {
+		t.Fatalf("expected timestamp before parent error, got %v", err)
+	}
+}
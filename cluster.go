@@ -1,9 +1,8 @@
 package clusterstate
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"math"
 )
 
 // Cluster represents a resulting cluster state at a given point in the DAG.
@@ -13,6 +12,7 @@ type Cluster struct {
 	Hashes            map[Hash]SignedMutation
 
 	Name               string
+	Creator            PublicKey
 	Operators          []Operator
 	NumValidators      int
 	WithdrawalAddress  string
@@ -20,15 +20,22 @@ type Cluster struct {
 	ParticipationProof []ParticipationProof
 }
 
+// Clone returns a deep copy of the cluster.
+// Note that mutation data and participation proofs are immutable and therefore not copied.
 func (c Cluster) Clone() Cluster {
-	b, err := json.Marshal(c)
-	if err != nil {
-		panic(err)
+	resp := c
+	resp.Hashes = make(map[Hash]SignedMutation, len(c.Hashes))
+	for hash, sm := range c.Hashes {
+		resp.Hashes[hash] = sm
 	}
 
-	var resp Cluster
-	if err := json.Unmarshal(b, &resp); err != nil {
-		panic(err)
+	resp.Operators = append([]Operator(nil), c.Operators...)
+	resp.ParticipationProof = append([]ParticipationProof(nil), c.ParticipationProof...)
+
+	resp.Validators = nil
+	for _, v := range c.Validators {
+		v.PublicShares = append([]PublicKey(nil), v.PublicShares...)
+		resp.Validators = append(resp.Validators, v)
 	}
 
 	return resp
@@ -41,39 +48,28 @@ type Operator struct {
 	ENR       string
 }
 
-// Resolve return the resulting cluster state at all heads/forks.
+// Resolve return the resulting cluster state at all heads/forks, in the order of State.Leaves.
 // It assumes that fork choice has already been applied to state.
 func Resolve(state State) ([]Cluster, error) {
 	if len(state) == 0 {
 		return nil, fmt.Errorf("empty state")
 	}
 
+	leaves := state.Leaves()
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("no heads, mutation hashes are cyclic")
+	}
+
 	var resp []Cluster
-	for _, leaf := range state.Leaves() {
+	for _, leaf := range leaves {
 		sequence, err := state.Sequence(leaf)
 		if err != nil {
 			return nil, err
 		}
 
-		if sequence[0].Mutation.Type != TypeCreateCluster {
-			return nil, fmt.Errorf("first mutation must be create cluster")
-		}
-
-		var cluster Cluster
-		for _, mutation := range sequence {
-			approvedBy, err := state.ApprovedBy(mutation.Hash)
-			if err != nil {
-				return nil, err
-			}
-
-			if !Approved(mutation.Mutation.Type.Approvals(), approvedBy, cluster) {
-				break
-			}
-
-			cluster, err = AppendToCluster(mutation, cluster)
-			if err != nil {
-				return nil, err
-			}
+		cluster, _, err := apply(state, sequence)
+		if err != nil && !errors.Is(err, ErrNotApproved) {
+			return nil, err
 		}
 
 		resp = append(resp, cluster)
@@ -82,22 +78,32 @@ func Resolve(state State) ([]Cluster, error) {
 	return resp, nil
 }
 
-func Approved(require Approvals, approvedBy map[PublicKey]bool, cluster Cluster) bool {
-	if require == ApprovalsNone {
-		return true
+// apply returns the cluster resulting from appending the sequence of mutations in order,
+// and the number of mutations appended. It stops at the first mutation that is not approved
+// or cannot be appended, returning the partial cluster and a MutationError.
+func apply(state State, sequence []SignedMutation) (Cluster, int, error) {
+	if len(sequence) == 0 {
+		return Cluster{}, 0, nil
+	} else if sequence[0].Mutation.Type != TypeCreateCluster {
+		return Cluster{}, 0, fmt.Errorf("first mutation must be create cluster")
 	}
 
-	var count int
-	for _, op := range cluster.Operators {
-		if approvedBy[op.PublicKey] {
-			count++
+	var cluster Cluster
+	children := state.children()
+	for i, mutation := range sequence {
+		// Only walk the descendants of mutations that require approvals.
+		policy := mutation.Mutation.Type.Approvals()
+		if !isNoApprovals(policy) && !Approved(policy, children.approvedBy(mutation.Hash), cluster) {
+			return cluster, i, newMutationError(mutation, ErrNotApproved)
+		}
+
+		next, err := AppendToCluster(mutation, cluster)
+		if err != nil {
+			return cluster, i, newMutationError(mutation, err)
 		}
-	}
 
-	if require == ApprovalsQuorum {
-		q := int(math.Ceil(float64(2*len(cluster.Operators)) / 3))
-		return count >= q
+		cluster = next
 	}
 
-	return count == len(cluster.Operators)
+	return cluster, len(sequence), nil
 }
@@ -0,0 +1,33 @@
+// Command conformance runs the lifecycle conformance suite against all cluster state models.
+//
+//	go run ./cmd/conformance
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/corverroos/clusterstate/conformance"
+)
+
+func main() {
+	var failed bool
+	for _, lifecycle := range conformance.Lifecycles() {
+		for _, model := range conformance.Models() {
+			err := conformance.Check(model, lifecycle)
+			if errors.Is(err, conformance.ErrUnsupported) {
+				fmt.Printf("skip %s/%s: %v\n", model.Name(), lifecycle.Name, err)
+			} else if err != nil {
+				fmt.Printf("FAIL %s/%s: %v\n", model.Name(), lifecycle.Name, err)
+				failed = true
+			} else {
+				fmt.Printf("ok   %s/%s\n", model.Name(), lifecycle.Name)
+			}
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
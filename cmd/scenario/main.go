@@ -0,0 +1,37 @@
+// Command scenario runs declarative DAG scenario files against the clusterstate package.
+//
+//	go run ./cmd/scenario testdata/scenarios/*.json
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/corverroos/clusterstate"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("usage: scenario <file.json>...")
+		os.Exit(2)
+	}
+
+	var failed bool
+	for _, file := range os.Args[1:] {
+		s, err := clusterstate.LoadScenario(file)
+		if err == nil {
+			err = clusterstate.RunScenario(s)
+		}
+
+		if err != nil {
+			fmt.Printf("FAIL %s: %s: %v\n", file, s.Name, err)
+			failed = true
+		} else {
+			fmt.Printf("ok   %s: %s\n", file, s.Name)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
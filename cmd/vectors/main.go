@@ -0,0 +1,154 @@
+// Command vectors verifies the golden test vectors in testdata/vectors against the current implementation,
+// or regenerates them with -update. The vectors are consumed by other language implementations.
+//
+//	go run ./cmd/vectors
+//	go run ./cmd/vectors -update
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	update = flag.Bool("update", false, "Regenerate the vectors instead of verifying them")
+	dir    = flag.String("dir", "testdata/vectors", "Directory containing the vectors")
+)
+
+// vectorFile is a sequence of mutations and the expected result of each.
+type vectorFile struct {
+	Description string
+	Steps       []vectorStep
+}
+
+// vectorStep is a serialised mutation, its hash and the expected cluster state after adding it.
+type vectorStep struct {
+	// Mutation is the canonical JSON serialised SignedMutation.
+	Mutation json.RawMessage
+	// HashInput is the exact bytes hashed with sha256 to obtain the hash.
+	HashInput string
+	// Hash is the hex encoded mutation hash.
+	Hash string
+	// Cluster is the expected cluster state after adding the mutation.
+	Cluster json.RawMessage
+}
+
+// files are the vector files and their generators.
+var files = []struct {
+	Name     string
+	Generate func() (vectorFile, error)
+}{
+	{Name: "root.json", Generate: rootVectors},
+	{Name: "v7.json", Generate: v7Vectors},
+}
+
+func main() {
+	flag.Parse()
+
+	var failed bool
+	for _, f := range files {
+		if err := run(filepath.Join(*dir, f.Name), f.Generate); err != nil {
+			fmt.Printf("FAIL %s: %v\n", f.Name, err)
+			failed = true
+		} else if *update {
+			fmt.Printf("updated %s\n", f.Name)
+		} else {
+			fmt.Printf("ok   %s\n", f.Name)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// run generates the vectors and writes or verifies the file.
+func run(file string, generate func() (vectorFile, error)) error {
+	vectors, err := generate()
+	if err != nil {
+		return err
+	}
+
+	b, err := marshal(vectors)
+	if err != nil {
+		return err
+	}
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return fmt.Errorf("create dir: %w", err)
+		}
+
+		return os.WriteFile(file, b, 0o644)
+	}
+
+	expect, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read vectors: %w", err)
+	} else if !bytes.Equal(b, expect) {
+		return fmt.Errorf("vectors do not match implementation, regenerate with -update if the change is intended")
+	}
+
+	return nil
+}
+
+// marshal returns the JSON encoded vectors with a step per line, keeping diffs of regenerated vectors readable.
+func marshal(vectors vectorFile) ([]byte, error) {
+	desc, err := json.Marshal(vectors.Description)
+	if err != nil {
+		return nil, fmt.Errorf("marshal description: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"Description":`)
+	buf.Write(desc)
+	buf.WriteString(",\n\"Steps\":[\n")
+	for i, step := range vectors.Steps {
+		b, err := json.Marshal(step)
+		if err != nil {
+			return nil, fmt.Errorf("marshal step: %w", err)
+		}
+
+		buf.Write(b)
+		if i < len(vectors.Steps)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("]}\n")
+
+	return buf.Bytes(), nil
+}
+
+// newStep returns a vector step, verifying that the hash input results in the hash.
+func newStep(mutation any, hashInput any, hash [32]byte, cluster any) (vectorStep, error) {
+	input, err := json.Marshal(hashInput)
+	if err != nil {
+		return vectorStep{}, fmt.Errorf("marshal hash input: %w", err)
+	} else if sha256.Sum256(input) != hash {
+		return vectorStep{}, fmt.Errorf("hash input does not match hash")
+	}
+
+	m, err := json.Marshal(mutation)
+	if err != nil {
+		return vectorStep{}, fmt.Errorf("marshal mutation: %w", err)
+	}
+
+	c, err := json.Marshal(cluster)
+	if err != nil {
+		return vectorStep{}, fmt.Errorf("marshal cluster: %w", err)
+	}
+
+	return vectorStep{
+		Mutation:  m,
+		HashInput: string(input),
+		Hash:      hex.EncodeToString(hash[:]),
+		Cluster:   c,
+	}, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testdata = "../../testdata/vectors"
+
+func TestVectors(t *testing.T) {
+	for _, f := range files {
+		t.Run(f.Name, func(t *testing.T) {
+			if err := run(filepath.Join(testdata, f.Name), f.Generate); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TestVectorHashes verifies the vectors the way other implementations consume them.
+func TestVectorHashes(t *testing.T) {
+	for _, f := range files {
+		t.Run(f.Name, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join(testdata, f.Name))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var vectors vectorFile
+			if err := json.Unmarshal(b, &vectors); err != nil {
+				t.Fatal(err)
+			} else if len(vectors.Steps) == 0 {
+				t.Fatal("no steps")
+			}
+
+			for i, step := range vectors.Steps {
+				hash := sha256.Sum256([]byte(step.HashInput))
+				if hex.EncodeToString(hash[:]) != step.Hash {
+					t.Fatalf("step %d: hash does not match hash input", i)
+				}
+			}
+		})
+	}
+}
+
+func TestVectorsMismatch(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "root.json")
+	if err := os.WriteFile(file, []byte(`{}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run(file, rootVectors); err == nil {
+		t.Fatal("expected mismatch error")
+	}
+}
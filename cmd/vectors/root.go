@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/corverroos/clusterstate"
+)
+
+// rootCluster is the serialised clusterstate.Cluster, with the mutations replaced by their sorted hex hashes.
+type rootCluster struct {
+	Height             int
+	ApprovedMutations  int
+	Hashes             []string
+	Name               string
+	Creator            clusterstate.PublicKey
+	Operators          []clusterstate.Operator
+	NumValidators      int
+	WithdrawalAddress  string
+	Validators         []clusterstate.Validator
+	ParticipationProof []clusterstate.ParticipationProof
+}
+
+// rootBuilder builds the root DAG, recording a vector step per mutation.
+type rootBuilder struct {
+	clock *clusterstate.FakeClock
+	state clusterstate.State
+	head  clusterstate.SignedMutation
+	steps []vectorStep
+	err   error
+}
+
+// rootVectors returns vectors for a lifecycle containing every builtin root mutation type.
+func rootVectors() (vectorFile, error) {
+	var (
+		b   = &rootBuilder{clock: clusterstate.NewFakeClock(time.Unix(0, 0).UTC())}
+		ops = []clusterstate.PublicKey{"operator-0", "operator-1", "operator-2", "operator-3"}
+	)
+
+	b.add(ops[0], clusterstate.TypeCreateCluster, clusterstate.CreateCluster{
+		Name:              "test-cluster",
+		Operators:         ops,
+		NumValidators:     1,
+		WithdrawalAddress: "0x1234567890",
+	})
+	for _, op := range ops {
+		b.add(op, clusterstate.TypeOperatorENR, clusterstate.OperatorENR{ENR: fmt.Sprintf("enr://%s", op)})
+	}
+
+	b.approved(ops, clusterstate.TypeGenerateValidators, clusterstate.GenerateValidators{
+		Validators: []clusterstate.Validator{rootValidator(0, "share", ops)},
+	})
+	b.approved(ops, clusterstate.TypeAddValidators, clusterstate.AddValidators{NumValidators: 1})
+	b.approved(ops, clusterstate.TypeGenerateValidators, clusterstate.GenerateValidators{
+		Validators: []clusterstate.Validator{rootValidator(1, "share", ops)},
+	})
+	b.approved(ops, clusterstate.TypeReshareValidators, clusterstate.ReshareValidators{
+		NewValidators: []clusterstate.Validator{rootValidator(0, "reshare", ops), rootValidator(1, "reshare", ops)},
+	})
+
+	newOps := []clusterstate.PublicKey{ops[0], ops[1], ops[2], "operator-4"}
+	b.approved(ops, clusterstate.TypeChangeOperators, clusterstate.ChangeOperators{NewOperators: newOps})
+
+	counts := make(map[clusterstate.PublicKey]int)
+	for _, op := range newOps {
+		counts[op] = 10
+	}
+	b.add(ops[0], clusterstate.TypeParticipationProof, clusterstate.ParticipationProof{
+		StartEpoch: 0,
+		EndEpoch:   9,
+		Validators: map[clusterstate.PublicKey]map[clusterstate.DutyType]map[clusterstate.PublicKey]int{
+			"validator-0": {0: counts},
+			"validator-1": {0: counts},
+		},
+	})
+
+	if b.err != nil {
+		return vectorFile{}, b.err
+	}
+
+	return vectorFile{
+		Description: "Root DAG lifecycle containing every builtin mutation type. Mutations are timestamped " +
+			"one second apart from the unix epoch, each building on the previous step, approvals building on the approved mutation. " +
+			"HashInput is the JSON of the mutation and its source. Cluster is the resolved cluster at the head of the mutation.",
+		Steps: b.steps,
+	}, nil
+}
+
+// approved adds the mutation by the first operator followed by acks by all operators,
+// subsequent mutations build on the last ack.
+func (b *rootBuilder) approved(ops []clusterstate.PublicKey, typ clusterstate.MutationType, data any) {
+	b.add(ops[0], typ, data)
+
+	proposal := b.head
+	for _, op := range ops {
+		b.head = proposal
+		b.add(op, clusterstate.TypeOperatorAck, clusterstate.OperatorAck{})
+	}
+}
+
+// add validates and adds the mutation building on the head, recording its vector step.
+func (b *rootBuilder) add(source clusterstate.PublicKey, typ clusterstate.MutationType, data any) {
+	if b.err != nil {
+		return
+	}
+
+	b.clock.Advance(time.Second)
+
+	var parents []clusterstate.SignedMutation
+	if len(b.state) > 0 {
+		parents = append(parents, b.head)
+	}
+
+	sm := clusterstate.NewSignedMutation(b.clock, source, typ, data, parents...)
+	if err := clusterstate.ValidateAddWithClock(b.clock, b.state, sm); err != nil {
+		b.err = fmt.Errorf("validate %s: %w", typ, err)
+		return
+	}
+	b.state = append(b.state, sm)
+	b.head = sm
+
+	reports, err := clusterstate.ResolveReport(b.state)
+	if err != nil {
+		b.err = fmt.Errorf("resolve %s: %w", typ, err)
+		return
+	}
+
+	var cluster clusterstate.Cluster
+	for _, report := range reports {
+		if report.Head == sm.Hash {
+			cluster = report.Cluster
+		}
+	}
+
+	hashInput := struct {
+		Mutation clusterstate.Mutation
+		Source   clusterstate.PublicKey
+	}{sm.Mutation, source}
+
+	step, err := newStep(sm, hashInput, sm.Hash, newRootCluster(cluster))
+	if err != nil {
+		b.err = fmt.Errorf("step %s: %w", typ, err)
+		return
+	}
+
+	b.steps = append(b.steps, step)
+}
+
+func newRootCluster(c clusterstate.Cluster) rootCluster {
+	var hashes []string
+	for hash := range c.Hashes {
+		hashes = append(hashes, hex.EncodeToString(hash[:]))
+	}
+	sort.Strings(hashes)
+
+	return rootCluster{
+		Height:             c.Height,
+		ApprovedMutations:  c.ApprovedMutations,
+		Hashes:             hashes,
+		Name:               c.Name,
+		Creator:            c.Creator,
+		Operators:          c.Operators,
+		NumValidators:      c.NumValidators,
+		WithdrawalAddress:  c.WithdrawalAddress,
+		Validators:         c.Validators,
+		ParticipationProof: c.ParticipationProof,
+	}
+}
+
+// rootValidator returns the deterministic validator with a share per operator.
+func rootValidator(i int, share string, ops []clusterstate.PublicKey) clusterstate.Validator {
+	v := clusterstate.Validator{PublicKey: clusterstate.PublicKey(fmt.Sprintf("validator-%d", i))}
+	for j := range ops {
+		v.PublicShares = append(v.PublicShares, clusterstate.PublicKey(fmt.Sprintf("validator-%d/%s-%d", i, share, j)))
+	}
+
+	return v
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	v7 "github.com/corverroos/clusterstate/v7"
+)
+
+// v7Builder builds the v7 RawDAG, recording a vector step per composite.
+type v7Builder struct {
+	ops        []v7.PublicKey
+	dag        v7.RawDAG
+	checkpoint []byte // Incremental materialiser checkpoint of the DAG.
+	steps      []vectorStep
+	err        error
+}
+
+// v7Vectors returns vectors for a DAG containing every v7 composite.
+func v7Vectors() (vectorFile, error) {
+	b := &v7Builder{ops: []v7.PublicKey{"operator-0", "operator-1", "operator-2", "operator-3"}}
+
+	b.add(v7.TypeCreateCluster, func(parent v7.Hash) []v7.SignedMutation {
+		var enrs v7.OperatorENRs
+		enrsParent := b.mutation(parent, v7.TypeOperatorENRs, nil)
+		for _, op := range b.ops {
+			enr := v7.Mutation{Parent: enrsParent.Mutation.HeaderHash(enrsParent.Source), Type: v7.TypeOperatorENR, Data: v7.OperatorENR{ENR: fmt.Sprintf("enr://%s", op)}}
+			enrs = append(enrs, v7.SignedMutation{Mutation: enr, Hash: enr.SignedHash(op), Source: op})
+		}
+
+		return []v7.SignedMutation{
+			b.mutation(parent, v7.TypeProposeCluster, v7.ProposeCluster{
+				Name:       "test-cluster",
+				Operators:  b.ops,
+				Validators: make([]v7.Validator, 1),
+			}),
+			seal(enrsParent, enrs),
+		}
+	})
+
+	b.add(v7.TypeGenerateValidators, func(parent v7.Hash) []v7.SignedMutation {
+		vals := v7.Validators{v7Validator(0, b.ops)}
+
+		return []v7.SignedMutation{
+			b.mutation(parent, v7.TypeDKG, vals),
+			b.acks(parent, b.ops, vals),
+		}
+	})
+
+	b.add(v7.TypeAddValidators, func(parent v7.Hash) []v7.SignedMutation {
+		return []v7.SignedMutation{
+			b.mutation(parent, v7.TypeProposeValidators, v7.Validators{v7Validator(1, b.ops)}),
+			b.approvals(parent, b.ops),
+		}
+	})
+
+	// Replace the last operator, resharing the validators to the new operators.
+	ops := append(append([]v7.PublicKey(nil), b.ops[:len(b.ops)-1]...), "operator-4")
+	b.add(v7.TypeReplaceOperators, func(parent v7.Hash) []v7.SignedMutation {
+		enrsParent := b.mutation(parent, v7.TypeNewOperatorENRs, nil)
+		enr := v7.Mutation{Parent: enrsParent.Mutation.HeaderHash(enrsParent.Source), Type: v7.TypeOperatorENR, Data: v7.OperatorENR{ENR: "enr://operator-4"}}
+		enrsParent = seal(enrsParent, v7.NewOperatorENRs{{Mutation: enr, Hash: enr.SignedHash("operator-4"), Source: "operator-4"}})
+
+		reshared := v7.Validators{v7Reshared(0, ops), v7Reshared(1, ops)}
+
+		return []v7.SignedMutation{
+			b.approvals(parent, b.ops),
+			b.mutation(parent, v7.TypeProposeOperators, v7.ProposeOperators{Operators: ops}),
+			enrsParent,
+			b.mutation(parent, v7.TypeReshare, reshared),
+			b.acks(parent, ops, reshared),
+		}
+	})
+	b.ops = ops
+
+	b.add(v7.TypeReshareValidators, func(parent v7.Hash) []v7.SignedMutation {
+		return []v7.SignedMutation{
+			b.mutation(parent, v7.TypeReshare, v7.Validators{v7Reshared(0, b.ops), v7Reshared(1, b.ops)}),
+			b.approvals(parent, b.ops),
+		}
+	})
+
+	b.add(v7.TypeExitValidators, func(parent v7.Hash) []v7.SignedMutation {
+		return []v7.SignedMutation{
+			b.mutation(parent, v7.TypeProposeExit, v7.ProposeExit{Validators: []v7.PublicKey{"validator-1"}}),
+			b.approvals(parent, b.ops),
+			b.mutation(parent, v7.TypeExitMessages, v7.ExitMessages{{
+				ValidatorPublicKey: "validator-1",
+				Epoch:              256,
+				Signature:          []byte("validator-1/exit"),
+			}}),
+		}
+	})
+
+	// Exit messages are optional.
+	b.add(v7.TypeExitValidators, func(parent v7.Hash) []v7.SignedMutation {
+		return []v7.SignedMutation{
+			b.mutation(parent, v7.TypeProposeExit, v7.ProposeExit{Validators: []v7.PublicKey{"validator-0"}}),
+			b.approvals(parent, b.ops),
+		}
+	})
+
+	if b.err != nil {
+		return vectorFile{}, b.err
+	}
+
+	return vectorFile{
+		Description: "v7 RawDAG containing every composite, created by the first operator unless created per operator. " +
+			"HashInput is the JSON of the composite's header hash and the ordered hashes of its children, where the header hash " +
+			"is the sha256 of the JSON of the mutation excluding its data and its source. Children refer to the header hash " +
+			"of their composite as their parent. Cluster is the materialised cluster state.",
+		Steps: b.steps,
+	}, nil
+}
+
+// add adds the composite with the children returned by the function given the composite's header hash,
+// recording its vector step.
+func (b *v7Builder) add(typ v7.MutationType, children func(header v7.Hash) []v7.SignedMutation) {
+	if b.err != nil {
+		return
+	}
+
+	var parent v7.Hash
+	if len(b.dag) > 0 {
+		parent = b.dag[len(b.dag)-1].Hash
+	}
+
+	composite := b.mutation(parent, typ, nil)
+	header := composite.Mutation.HeaderHash(composite.Source)
+	kids := children(header)
+
+	hashInput := struct {
+		Header   v7.Hash
+		Children []v7.Hash
+	}{Header: header}
+	for _, kid := range kids {
+		hashInput.Children = append(hashInput.Children, kid.Hash)
+	}
+
+	// Composite data is the array or slice of children.
+	switch typ {
+	case v7.TypeCreateCluster:
+		composite = seal(composite, v7.CreateCluster(kids))
+	case v7.TypeGenerateValidators:
+		composite = seal(composite, v7.GenerateValidators(kids))
+	case v7.TypeAddValidators:
+		composite = seal(composite, v7.AddValidators(kids))
+	case v7.TypeReplaceOperators:
+		composite = seal(composite, v7.ReplaceOperators(kids))
+	case v7.TypeReshareValidators:
+		composite = seal(composite, v7.ReshareValidators(kids))
+	case v7.TypeExitValidators:
+		composite = seal(composite, v7.ExitValidators(kids))
+	default:
+		b.err = fmt.Errorf("unknown composite: %s", typ)
+		return
+	}
+
+	b.dag = append(b.dag, composite)
+
+	state, err := v7.MaterialiseDV(b.dag)
+	if err != nil {
+		b.err = fmt.Errorf("materialise %s: %w", typ, err)
+		return
+	}
+
+	if err := b.resumeAndApply(composite, state); err != nil {
+		b.err = fmt.Errorf("incremental materialise %s: %w", typ, err)
+		return
+	}
+
+	step, err := newStep(composite, hashInput, composite.Hash, state)
+	if err != nil {
+		b.err = fmt.Errorf("step %s: %w", typ, err)
+		return
+	}
+
+	b.steps = append(b.steps, step)
+}
+
+// resumeAndApply applies the composite to the materialiser resumed from the previous checkpoint,
+// verifying the resulting state matches the expected state.
+func (b *v7Builder) resumeAndApply(composite v7.SignedMutation, expect v7.ClusterState) error {
+	m := v7.NewMaterialiser()
+	if b.checkpoint != nil {
+		var err error
+		m, err = v7.ResumeMaterialiser(b.checkpoint)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := m.Apply(composite); err != nil {
+		return err
+	}
+
+	actual, err := json.Marshal(m.State())
+	if err != nil {
+		return err
+	}
+	expected, err := json.Marshal(expect)
+	if err != nil {
+		return err
+	} else if !bytes.Equal(actual, expected) {
+		return fmt.Errorf("state mismatch")
+	}
+
+	b.checkpoint, err = m.Checkpoint()
+
+	return err
+}
+
+// mutation returns a mutation created by the first operator.
+func (b *v7Builder) mutation(parent v7.Hash, typ v7.MutationType, data any) v7.SignedMutation {
+	m := v7.Mutation{Parent: parent, Type: typ, Data: data}
+
+	return v7.SignedMutation{Mutation: m, Hash: m.SignedHash(b.ops[0]), Source: b.ops[0]}
+}
+
+// approvals returns the approvals composite with an approval from each operator.
+func (b *v7Builder) approvals(parent v7.Hash, ops []v7.PublicKey) v7.SignedMutation {
+	var approvals v7.OperatorApprovals
+	approvalsParent := b.mutation(parent, v7.TypeOperatorApprovals, nil)
+	for _, op := range ops {
+		approval := v7.Mutation{Parent: approvalsParent.Mutation.HeaderHash(approvalsParent.Source), Type: v7.TypeOperatorApproval}
+		approvals = append(approvals, v7.SignedMutation{Mutation: approval, Hash: approval.SignedHash(op), Source: op})
+	}
+
+	return seal(approvalsParent, approvals)
+}
+
+// acks returns the validator acks composite with an ack from each operator of its shares of the validators.
+func (b *v7Builder) acks(parent v7.Hash, ops []v7.PublicKey, vals v7.Validators) v7.SignedMutation {
+	var acks v7.ValidatorAcks
+	acksParent := b.mutation(parent, v7.TypeValidatorAcks, nil)
+	for i, op := range ops {
+		var data v7.ValidatorAck
+		for _, val := range vals {
+			data.Shares = append(data.Shares, v7.ShareAck{
+				ValidatorPublicKey: val.PublicKey,
+				ShareCommitment:    v7.ShareCommitment(val.PublicKey, op, val.PublicShares[i]),
+			})
+		}
+
+		ack := v7.Mutation{Parent: acksParent.Mutation.HeaderHash(acksParent.Source), Type: v7.TypeValidatorAck, Data: data}
+		acks = append(acks, v7.SignedMutation{Mutation: ack, Hash: ack.SignedHash(op), Source: op})
+	}
+
+	return seal(acksParent, acks)
+}
+
+// seal returns the composite with the children data and its hash committing to them.
+func seal(composite v7.SignedMutation, data any) v7.SignedMutation {
+	composite.Mutation.Data = data
+	composite.Hash = composite.Mutation.SignedHash(composite.Source)
+
+	return composite
+}
+
+// v7Validator returns the deterministic validator with a share per operator.
+func v7Validator(i int, ops []v7.PublicKey) v7.Validator {
+	v := v7.Validator{PublicKey: v7.PublicKey(fmt.Sprintf("validator-%d", i))}
+	for j := range ops {
+		v.PublicShares = append(v.PublicShares, v7.PublicKey(fmt.Sprintf("validator-%d/share-%d", i, j)))
+	}
+
+	return v
+}
+
+// v7Reshared returns the deterministic validator with shares reshared to the operators.
+func v7Reshared(i int, ops []v7.PublicKey) v7.Validator {
+	v := v7.Validator{PublicKey: v7.PublicKey(fmt.Sprintf("validator-%d", i))}
+	for _, op := range ops {
+		v.PublicShares = append(v.PublicShares, v7.PublicKey(fmt.Sprintf("validator-%d/reshare-%s", i, op)))
+	}
+
+	return v
+}
@@ -0,0 +1,229 @@
+// Package conformance defines cluster lifecycle scenarios once and executes them against
+// each cluster state model (root DAG Resolve, v5 Materialise and v7 MaterialiseDV) via adapters,
+// asserting equivalent resulting operators and validators.
+package conformance
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrUnsupported is returned by a model that cannot represent a lifecycle step.
+var ErrUnsupported = errors.New("unsupported step")
+
+// StepType is a model agnostic cluster lifecycle step.
+type StepType string
+
+const (
+	// StepCreate proposes a new cluster.
+	StepCreate StepType = "create"
+	// StepENRs submits the ENRs of all operators.
+	StepENRs StepType = "enrs"
+	// StepDKG generates all missing validators, acknowledged by all operators.
+	StepDKG StepType = "dkg"
+	// StepAddValidators adds validators, approved by all operators.
+	StepAddValidators StepType = "add_validators"
+	// StepRegisterValidators registers the fee recipient of all validators, approved by all operators.
+	StepRegisterValidators StepType = "register_validators"
+)
+
+// Step is a lifecycle step.
+type Step struct {
+	Type StepType
+	// Name is the cluster name, used by StepCreate.
+	Name string
+	// Operators are the cluster operators, used by StepCreate.
+	Operators []string
+	// NumValidators is the number of validators, used by StepCreate and StepAddValidators.
+	NumValidators int
+	// FeeRecipient is the validator fee recipient, used by StepRegisterValidators.
+	FeeRecipient string
+	// Approvals is the number of operators, in operator order, approving StepAddValidators and
+	// StepRegisterValidators, it defaults to all.
+	// Models must not apply proposals approved by fewer operators than they require.
+	Approvals int
+}
+
+// Lifecycle is a model agnostic lifecycle scenario.
+type Lifecycle struct {
+	Name   string
+	Steps  []Step
+	Expect Result
+}
+
+// Result is the model agnostic cluster state resulting from a lifecycle.
+type Result struct {
+	Name       string
+	Operators  []Operator
+	Validators []Validator
+}
+
+// Operator is a model agnostic cluster operator.
+type Operator struct {
+	PublicKey string
+	ENR       string
+}
+
+// Validator is a model agnostic distributed validator.
+type Validator struct {
+	PublicKey    string
+	PublicShares []string
+	FeeRecipient string
+}
+
+// Model is an adapter executing lifecycles against a cluster state model.
+type Model interface {
+	// Name returns the name of the model.
+	Name() string
+	// Run executes the lifecycle steps returning the resulting cluster state.
+	// It returns ErrUnsupported if the model cannot represent a step.
+	Run(steps []Step) (Result, error)
+}
+
+// Models returns adapters for all cluster state models.
+func Models() []Model {
+	return []Model{rootModel{}, v5Model{}, v7Model{}}
+}
+
+// Check executes the lifecycle against the model, returning an error if the result is not as expected.
+func Check(model Model, lifecycle Lifecycle) error {
+	res, err := model.Run(lifecycle.Steps)
+	if err != nil {
+		return err
+	}
+
+	if res.Name != lifecycle.Expect.Name {
+		return fmt.Errorf("name %s != %s", res.Name, lifecycle.Expect.Name)
+	} else if !reflect.DeepEqual(res.Operators, lifecycle.Expect.Operators) {
+		return fmt.Errorf("operators %v != %v", res.Operators, lifecycle.Expect.Operators)
+	} else if !reflect.DeepEqual(res.Validators, lifecycle.Expect.Validators) {
+		return fmt.Errorf("validators %v != %v", res.Validators, lifecycle.Expect.Validators)
+	}
+
+	return nil
+}
+
+// Lifecycles returns the builtin lifecycle scenarios.
+func Lifecycles() []Lifecycle {
+	ops := []string{"operator-0", "operator-1", "operator-2", "operator-3"}
+	create := Step{Type: StepCreate, Name: "test-cluster", Operators: ops, NumValidators: 2}
+	const feeRecipient = "0x000000000000000000000000000000000000fee0"
+
+	return []Lifecycle{
+		{
+			Name:  "create",
+			Steps: []Step{create},
+			Expect: Result{
+				Name:       "test-cluster",
+				Operators:  newOperators(ops, false),
+				Validators: newValidators(0, 0, 0),
+			},
+		},
+		{
+			Name:  "create_enrs",
+			Steps: []Step{create, {Type: StepENRs}},
+			Expect: Result{
+				Name:       "test-cluster",
+				Operators:  newOperators(ops, true),
+				Validators: newValidators(0, 0, 0),
+			},
+		},
+		{
+			Name:  "create_enrs_dkg",
+			Steps: []Step{create, {Type: StepENRs}, {Type: StepDKG}},
+			Expect: Result{
+				Name:       "test-cluster",
+				Operators:  newOperators(ops, true),
+				Validators: newValidators(0, 2, len(ops)),
+			},
+		},
+		{
+			Name:  "add_validators",
+			Steps: []Step{create, {Type: StepENRs}, {Type: StepDKG}, {Type: StepAddValidators, NumValidators: 3}},
+			Expect: Result{
+				Name:       "test-cluster",
+				Operators:  newOperators(ops, true),
+				Validators: newValidators(0, 5, len(ops)),
+			},
+		},
+		{
+			Name: "add_validators_partially_approved",
+			Steps: []Step{
+				create, {Type: StepENRs}, {Type: StepDKG},
+				{Type: StepAddValidators, NumValidators: 3, Approvals: len(ops) / 2},
+			},
+			Expect: Result{
+				Name:       "test-cluster",
+				Operators:  newOperators(ops, true),
+				Validators: newValidators(0, 2, len(ops)),
+			},
+		},
+		{
+			Name: "register_validators",
+			Steps: []Step{
+				create, {Type: StepENRs}, {Type: StepDKG},
+				{Type: StepRegisterValidators, FeeRecipient: feeRecipient},
+			},
+			Expect: Result{
+				Name:       "test-cluster",
+				Operators:  newOperators(ops, true),
+				Validators: withFeeRecipient(newValidators(0, 2, len(ops)), feeRecipient),
+			},
+		},
+		{
+			Name: "register_validators_partially_approved",
+			Steps: []Step{
+				create, {Type: StepENRs}, {Type: StepDKG},
+				{Type: StepRegisterValidators, FeeRecipient: feeRecipient, Approvals: len(ops) / 2},
+			},
+			Expect: Result{
+				Name:       "test-cluster",
+				Operators:  newOperators(ops, true),
+				Validators: newValidators(0, 2, len(ops)),
+			},
+		},
+	}
+}
+
+// withFeeRecipient returns the validators with the fee recipient.
+func withFeeRecipient(vals []Validator, feeRecipient string) []Validator {
+	for i := range vals {
+		vals[i].FeeRecipient = feeRecipient
+	}
+
+	return vals
+}
+
+// newOperators returns the expected operators, optionally with ENRs.
+func newOperators(keys []string, enrs bool) []Operator {
+	var resp []Operator
+	for _, key := range keys {
+		op := Operator{PublicKey: key}
+		if enrs {
+			op.ENR = newENR(key)
+		}
+		resp = append(resp, op)
+	}
+
+	return resp
+}
+
+// newValidators returns the deterministic validators with indexes in the range [from, to).
+func newValidators(from, to, numOperators int) []Validator {
+	var resp []Validator
+	for i := from; i < to; i++ {
+		v := Validator{PublicKey: fmt.Sprintf("validator-%d", i)}
+		for j := 0; j < numOperators; j++ {
+			v.PublicShares = append(v.PublicShares, fmt.Sprintf("validator-%d/share-%d", i, j))
+		}
+		resp = append(resp, v)
+	}
+
+	return resp
+}
+
+// newENR returns the deterministic ENR of the operator.
+func newENR(operator string) string {
+	return "enr://" + operator
+}
@@ -0,0 +1,54 @@
+package conformance
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	for _, model := range Models() {
+		for _, lifecycle := range Lifecycles() {
+			t.Run(model.Name()+"/"+lifecycle.Name, func(t *testing.T) {
+				err := Check(model, lifecycle)
+				if errors.Is(err, ErrUnsupported) {
+					t.Skip(err)
+				} else if err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	}
+}
+
+// stubModel returns a fixed result for all lifecycles.
+type stubModel struct {
+	result Result
+}
+
+func (stubModel) Name() string {
+	return "stub"
+}
+
+func (m stubModel) Run([]Step) (Result, error) {
+	return m.result, nil
+}
+
+func TestCheckPartiallyApproved(t *testing.T) {
+	var lifecycle Lifecycle
+	for _, l := range Lifecycles() {
+		if l.Name == "add_validators_partially_approved" {
+			lifecycle = l
+		}
+	}
+
+	if err := Check(stubModel{result: lifecycle.Expect}, lifecycle); err != nil {
+		t.Fatal(err)
+	}
+
+	// A model that applies the partially approved proposal fails the check.
+	applied := lifecycle.Expect
+	applied.Validators = newValidators(0, 5, len(applied.Operators))
+	if err := Check(stubModel{result: applied}, lifecycle); err == nil {
+		t.Fatal("expected applied partially approved proposal to fail")
+	}
+}
@@ -0,0 +1,189 @@
+package conformance
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/corverroos/clusterstate"
+)
+
+// rootModel executes lifecycles against the root DAG model via ValidateAdd and Resolve.
+type rootModel struct{}
+
+func (rootModel) Name() string {
+	return "root"
+}
+
+func (rootModel) Run(steps []Step) (Result, error) {
+	r := rootRunner{clock: clusterstate.NewFakeClock(time.Unix(0, 0).UTC())}
+
+	for i, step := range steps {
+		var err error
+		switch step.Type {
+		case StepCreate:
+			err = r.create(step)
+		case StepENRs:
+			err = r.enrs()
+		case StepDKG:
+			err = r.dkg()
+		case StepAddValidators:
+			err = r.addValidators(step.NumValidators, step.Approvals)
+		default:
+			err = ErrUnsupported
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("step %d %s: %w", i, step.Type, err)
+		}
+	}
+
+	cluster, err := r.cluster()
+	if err != nil {
+		return Result{}, err
+	}
+
+	resp := Result{Name: cluster.Name}
+	for _, op := range cluster.Operators {
+		resp.Operators = append(resp.Operators, Operator{PublicKey: string(op.PublicKey), ENR: op.ENR})
+	}
+	for _, v := range cluster.Validators {
+		val := Validator{PublicKey: string(v.PublicKey)}
+		for _, share := range v.PublicShares {
+			val.PublicShares = append(val.PublicShares, string(share))
+		}
+		resp.Validators = append(resp.Validators, val)
+	}
+
+	return resp, nil
+}
+
+// rootRunner builds the root DAG for a lifecycle.
+type rootRunner struct {
+	clock     *clusterstate.FakeClock
+	state     clusterstate.State
+	creator   clusterstate.PublicKey
+	operators []clusterstate.PublicKey
+	// heads are the parents of the next step.
+	heads []clusterstate.SignedMutation
+}
+
+func (r *rootRunner) create(step Step) error {
+	r.creator = clusterstate.PublicKey(step.Operators[0])
+	for _, op := range step.Operators {
+		r.operators = append(r.operators, clusterstate.PublicKey(op))
+	}
+
+	sm, err := r.add(r.creator, clusterstate.TypeCreateCluster, clusterstate.CreateCluster{
+		Name:          step.Name,
+		Operators:     r.operators,
+		NumValidators: step.NumValidators,
+	}, r.heads)
+	if err != nil {
+		return err
+	}
+	r.heads = []clusterstate.SignedMutation{sm}
+
+	return nil
+}
+
+// enrs adds a linear chain of operator ENRs.
+func (r *rootRunner) enrs() error {
+	for _, op := range r.operators {
+		sm, err := r.add(op, clusterstate.TypeOperatorENR, clusterstate.OperatorENR{ENR: newENR(string(op))}, r.heads)
+		if err != nil {
+			return err
+		}
+		r.heads = []clusterstate.SignedMutation{sm}
+	}
+
+	return nil
+}
+
+// dkg generates the missing validators, acked by all operators.
+func (r *rootRunner) dkg() error {
+	cluster, err := r.cluster()
+	if err != nil {
+		return err
+	}
+
+	var gv clusterstate.GenerateValidators
+	for _, v := range newValidators(len(cluster.Validators), cluster.NumValidators, len(cluster.Operators)) {
+		val := clusterstate.Validator{PublicKey: clusterstate.PublicKey(v.PublicKey)}
+		for _, share := range v.PublicShares {
+			val.PublicShares = append(val.PublicShares, clusterstate.PublicKey(share))
+		}
+		gv.Validators = append(gv.Validators, val)
+	}
+
+	return r.addApproved(clusterstate.TypeGenerateValidators, gv, 0)
+}
+
+// addValidators adds validators by increasing the number of validators, approved by the first
+// approvals operators (or all if zero), and generating them if the increase was approved.
+func (r *rootRunner) addValidators(n int, approvals int) error {
+	if err := r.addApproved(clusterstate.TypeAddValidators, clusterstate.AddValidators{NumValidators: n}, approvals); err != nil {
+		return err
+	}
+
+	cluster, err := r.cluster()
+	if err != nil {
+		return err
+	} else if len(cluster.Validators) == cluster.NumValidators {
+		return nil // Not approved.
+	}
+
+	return r.dkg()
+}
+
+// addApproved adds the mutation by the creator followed by acks from the first approvals operators, or all if zero.
+func (r *rootRunner) addApproved(typ clusterstate.MutationType, data any, approvals int) error {
+	sm, err := r.add(r.creator, typ, data, r.heads)
+	if err != nil {
+		return err
+	}
+
+	approvers := r.operators
+	if approvals > 0 {
+		approvers = approvers[:approvals]
+	}
+
+	// Subsequent steps build on the last ack, since each ack is a separate fork.
+	for _, op := range approvers {
+		ack, err := r.add(op, clusterstate.TypeOperatorAck, clusterstate.OperatorAck{}, []clusterstate.SignedMutation{sm})
+		if err != nil {
+			return err
+		}
+		r.heads = []clusterstate.SignedMutation{ack}
+	}
+
+	return nil
+}
+
+// add validates and adds a new mutation to the state.
+func (r *rootRunner) add(source clusterstate.PublicKey, typ clusterstate.MutationType, data any, parents []clusterstate.SignedMutation) (clusterstate.SignedMutation, error) {
+	r.clock.Advance(time.Second)
+
+	sm := clusterstate.NewSignedMutation(r.clock, source, typ, data, parents...)
+	if err := clusterstate.ValidateAddWithClock(r.clock, r.state, sm); err != nil {
+		return clusterstate.SignedMutation{}, err
+	}
+	r.state = append(r.state, sm)
+
+	return sm, nil
+}
+
+// cluster returns the resolved cluster with the most applied mutations.
+func (r *rootRunner) cluster() (clusterstate.Cluster, error) {
+	clusters, err := clusterstate.Resolve(r.state)
+	if err != nil {
+		return clusterstate.Cluster{}, err
+	}
+
+	var resp clusterstate.Cluster
+	for _, cluster := range clusters {
+		if cluster.Height > resp.Height {
+			resp = cluster
+		}
+	}
+
+	return resp, nil
+}
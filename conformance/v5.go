@@ -0,0 +1,165 @@
+package conformance
+
+import (
+	"fmt"
+	"strings"
+
+	v5 "github.com/corverroos/clusterstate/v5"
+)
+
+// v5Model executes lifecycles against the v5 operation model via Materialise.
+type v5Model struct{}
+
+func (v5Model) Name() string {
+	return "v5"
+}
+
+func (v5Model) Run(steps []Step) (Result, error) {
+	r := new(v5Runner)
+	for i, step := range steps {
+		switch step.Type {
+		case StepCreate:
+			var ops []v5.PublicKey
+			for _, op := range step.Operators {
+				ops = append(ops, v5.PublicKey(op))
+			}
+			r.operators = ops
+			r.numValidators = step.NumValidators
+
+			r.beginOperation(ops[0], v5.TypeProposeCluster, v5.ProposeCluster{
+				Name:       step.Name,
+				Operators:  ops,
+				Validators: make([]v5.Validator, step.NumValidators),
+			})
+		case StepENRs:
+			begin := r.beginOperation(r.operators[0], v5.TypeAcceptClusterBegin, nil)
+			for _, op := range r.operators {
+				r.add(begin, op, v5.TypeOperatorENR, v5.OperatorENR{ENR: newENR(string(op))})
+			}
+			r.add(begin, r.operators[0], v5.TypeAcceptClusterEnd, nil)
+		case StepDKG:
+			var vals v5.Validators
+			for _, v := range newValidators(0, r.numValidators, len(r.operators)) {
+				val := v5.Validator{PublicKey: v5.PublicKey(v.PublicKey)}
+				for _, share := range v.PublicShares {
+					val.PublicShares = append(val.PublicShares, v5.PublicKey(share))
+				}
+				vals = append(vals, val)
+			}
+			r.beginOperation(r.operators[0], v5.TypeDKG, vals)
+		case StepRegisterValidators:
+			if err := r.register(step); err != nil {
+				return Result{}, fmt.Errorf("step %d %s: %w", i, step.Type, err)
+			}
+		default:
+			return Result{}, fmt.Errorf("step %d %s: %w", i, step.Type, ErrUnsupported)
+		}
+	}
+
+	state, err := v5.Materialise(r.dag)
+	if err != nil {
+		return Result{}, err
+	}
+
+	resp := Result{Name: state.Name}
+	for _, op := range state.Operators {
+		resp.Operators = append(resp.Operators, Operator{PublicKey: string(op.PublicKey), ENR: op.ENR})
+	}
+	for _, v := range state.Validators {
+		if v.PublicKey == "" {
+			continue // Skip validator placeholders proposed but not yet generated.
+		}
+
+		val := Validator{PublicKey: string(v.PublicKey), FeeRecipient: v.FeeRecipient}
+		for _, share := range v.PublicShares {
+			val.PublicShares = append(val.PublicShares, string(share))
+		}
+		resp.Validators = append(resp.Validators, val)
+	}
+
+	return resp, nil
+}
+
+// v5Runner builds the v5 DAG for a lifecycle.
+type v5Runner struct {
+	dag           v5.RawDAG
+	operators     []v5.PublicKey
+	numValidators int
+	// operation is the begin mutation of the latest operation.
+	operation v5.SignedMutation
+}
+
+// beginOperation adds a mutation beginning a new operation that builds on the latest operation.
+func (r *v5Runner) beginOperation(source v5.PublicKey, typ v5.MutationType, data any) v5.SignedMutation {
+	m := v5.Mutation{
+		ParentOperationHash: r.operation.Hash,
+		Type:                typ,
+		Data:                data,
+	}
+	if len(r.dag) > 0 {
+		m.ParentMutationHashes = []v5.Hash{r.dag[len(r.dag)-1].Hash}
+	}
+
+	r.operation = r.append(source, m)
+
+	return r.operation
+}
+
+// register adds a register validators operation for all validators, approved by step.Approvals operators.
+// The operation is dropped if it is rejected since it is approved by fewer than a quorum of operators.
+func (r *v5Runner) register(step Step) error {
+	state, err := v5.Materialise(r.dag)
+	if err != nil {
+		return err
+	}
+
+	approvals := r.operators
+	if step.Approvals > 0 && step.Approvals < len(approvals) {
+		approvals = approvals[:step.Approvals]
+	}
+
+	dag, operation := r.dag, r.operation
+
+	begin := r.beginOperation(r.operators[0], v5.TypeRegisterValidatorsBegin, nil)
+	for _, v := range state.Validators {
+		r.add(begin, r.operators[0], v5.TypeValidatorRegistration, v5.ValidatorRegistration{
+			Validator:    v.PublicKey,
+			FeeRecipient: step.FeeRecipient,
+		})
+	}
+	for _, op := range approvals {
+		r.add(begin, op, v5.TypeOperatorApproval, nil)
+	}
+	r.add(begin, r.operators[0], v5.TypeRegisterValidatorsEnd, nil)
+
+	// The end mutation is unexpected while the approvals spread has not reached a quorum.
+	_, err = v5.Materialise(r.dag)
+	if err != nil && len(approvals) < len(r.operators) &&
+		strings.Contains(err.Error(), "unexpected type "+string(v5.TypeRegisterValidatorsEnd)) {
+		r.dag, r.operation = dag, operation
+		return nil
+	}
+
+	return err
+}
+
+// add adds a mutation to the operation begun by the begin mutation.
+func (r *v5Runner) add(begin v5.SignedMutation, source v5.PublicKey, typ v5.MutationType, data any) {
+	r.append(source, v5.Mutation{
+		ParentMutationHashes: []v5.Hash{begin.Hash},
+		ParentOperationHash:  begin.Mutation.ParentOperationHash,
+		Type:                 typ,
+		Data:                 data,
+	})
+}
+
+func (r *v5Runner) append(source v5.PublicKey, m v5.Mutation) v5.SignedMutation {
+	sm := v5.SignedMutation{
+		Mutation: m,
+		Hash:     m.Hash(source),
+		Source:   source,
+	}
+	r.dag = append(r.dag, sm)
+
+	return sm
+}
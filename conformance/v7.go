@@ -0,0 +1,256 @@
+package conformance
+
+import (
+	"fmt"
+	"strings"
+
+	v7 "github.com/corverroos/clusterstate/v7"
+)
+
+// v7Model executes lifecycles against the v7 composite model via MaterialiseDV.
+type v7Model struct{}
+
+func (v7Model) Name() string {
+	return "v7"
+}
+
+func (v7Model) Run(steps []Step) (Result, error) {
+	var (
+		dag       v7.RawDAG
+		parent    v7.Hash
+		operators []v7.PublicKey
+		state     v7.ClusterState
+	)
+	for i := 0; i < len(steps); i++ {
+		step := steps[i]
+
+		var (
+			composite v7.SignedMutation
+			err       error
+		)
+		switch step.Type {
+		case StepCreate:
+			operators = nil
+			for _, op := range step.Operators {
+				operators = append(operators, v7.PublicKey(op))
+			}
+
+			// The v7 CreateCluster composite includes the operator ENRs, so it is only materialised
+			// with the ENRs step. Without it, the result is the proposal pending the operator ENRs.
+			if i+1 < len(steps) && steps[i+1].Type == StepENRs {
+				i++
+				composite, err = v7CreateCluster(parent, step, operators)
+			} else if i+1 < len(steps) {
+				return Result{}, fmt.Errorf("step %d %s without enrs: %w", i, step.Type, ErrUnsupported)
+			} else {
+				state, err = v7ProposeCluster(step, operators)
+				if err != nil {
+					return Result{}, fmt.Errorf("step %d %s: %w", i, step.Type, err)
+				}
+
+				continue
+			}
+		case StepDKG:
+			composite, err = v7GenerateValidators(parent, state, operators)
+		case StepAddValidators:
+			composite, err = v7AddValidators(parent, state, step, operators)
+		default:
+			err = ErrUnsupported
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("step %d %s: %w", i, step.Type, err)
+		}
+
+		next, err := v7.MaterialiseDV(append(dag[:len(dag):len(dag)], composite))
+		if err != nil && step.Approvals > 0 && step.Approvals < len(operators) &&
+			strings.Contains(err.Error(), "number of parallel mutations does not match number of operators") {
+			continue // Partially approved composites are rejected, so are never added to the DAG.
+		} else if err != nil {
+			return Result{}, fmt.Errorf("step %d %s: %w", i, step.Type, err)
+		}
+
+		dag = append(dag, composite)
+		parent = composite.Hash
+		state = next
+	}
+
+	resp := Result{Name: state.Name}
+	for _, op := range state.Operators {
+		resp.Operators = append(resp.Operators, Operator{PublicKey: string(op.PublicKey), ENR: op.ENR})
+	}
+	for _, v := range state.Validators {
+		if v.PublicKey == "" {
+			continue // Skip validator placeholders proposed but not yet generated.
+		}
+
+		val := Validator{PublicKey: string(v.PublicKey)}
+		for _, share := range v.PublicShares {
+			val.PublicShares = append(val.PublicShares, string(share))
+		}
+		resp.Validators = append(resp.Validators, val)
+	}
+
+	return resp, nil
+}
+
+func v7CreateCluster(parent v7.Hash, step Step, operators []v7.PublicKey) (v7.SignedMutation, error) {
+	composite := v7Mutation(parent, operators[0], v7.TypeCreateCluster, nil)
+	header := v7Header(composite)
+
+	propose := v7Mutation(header, operators[0], v7.TypeProposeCluster, v7.ProposeCluster{
+		Name:       step.Name,
+		Operators:  operators,
+		Validators: make([]v7.Validator, step.NumValidators),
+	})
+
+	// The ENRs are transformed with the state resulting from the proposal.
+	var state v7.ClusterState
+	for _, op := range operators {
+		state.Operators = append(state.Operators, v7.Operator{PublicKey: op})
+	}
+
+	enrs, err := v7Parallel(header, state, v7.TypeOperatorENRs, func(parent v7.Hash, op v7.PublicKey) v7.SignedMutation {
+		return v7Mutation(parent, op, v7.TypeOperatorENR, v7.OperatorENR{ENR: newENR(string(op))})
+	})
+	if err != nil {
+		return v7.SignedMutation{}, err
+	}
+
+	return v7Seal(composite, v7.CreateCluster{propose, enrs}), nil
+}
+
+// v7ProposeCluster returns the state resulting from the cluster proposal of a CreateCluster composite.
+func v7ProposeCluster(step Step, operators []v7.PublicKey) (v7.ClusterState, error) {
+	propose := v7Mutation(v7.Hash{}, operators[0], v7.TypeProposeCluster, v7.ProposeCluster{
+		Name:       step.Name,
+		Operators:  operators,
+		Validators: make([]v7.Validator, step.NumValidators),
+	})
+
+	return v7.TypeProposeCluster.Transform(v7.ClusterState{}, propose)
+}
+
+func v7GenerateValidators(parent v7.Hash, state v7.ClusterState, operators []v7.PublicKey) (v7.SignedMutation, error) {
+	composite := v7Mutation(parent, operators[0], v7.TypeGenerateValidators, nil)
+	header := v7Header(composite)
+
+	vals := v7Validators(newValidators(0, len(state.Validators), len(operators)))
+	dkg := v7Mutation(header, operators[0], v7.TypeDKG, vals)
+
+	shareIdx := make(map[v7.PublicKey]int)
+	for i, op := range operators {
+		shareIdx[op] = i
+	}
+
+	// Each operator acks the share commitment of its share of each validator.
+	acks, err := v7Parallel(header, state, v7.TypeValidatorAcks, func(parent v7.Hash, op v7.PublicKey) v7.SignedMutation {
+		var ack v7.ValidatorAck
+		for _, val := range vals {
+			ack.Shares = append(ack.Shares, v7.ShareAck{
+				ValidatorPublicKey: val.PublicKey,
+				ShareCommitment:    v7.ShareCommitment(val.PublicKey, op, val.PublicShares[shareIdx[op]]),
+			})
+		}
+
+		return v7Mutation(parent, op, v7.TypeValidatorAck, ack)
+	})
+	if err != nil {
+		return v7.SignedMutation{}, err
+	}
+
+	return v7Seal(composite, v7.GenerateValidators{dkg, acks}), nil
+}
+
+func v7AddValidators(parent v7.Hash, state v7.ClusterState, step Step, operators []v7.PublicKey) (v7.SignedMutation, error) {
+	composite := v7Mutation(parent, operators[0], v7.TypeAddValidators, nil)
+	header := v7Header(composite)
+
+	propose := v7Mutation(header, operators[0], v7.TypeProposeValidators,
+		v7Validators(newValidators(len(state.Validators), len(state.Validators)+step.NumValidators, len(operators))))
+
+	newApproval := func(parent v7.Hash, op v7.PublicKey) v7.SignedMutation {
+		return v7Mutation(parent, op, v7.TypeOperatorApproval, nil)
+	}
+
+	var (
+		approvals v7.SignedMutation
+		err       error
+	)
+	if step.Approvals > 0 && step.Approvals < len(operators) {
+		// The builder refuses incomplete composites, so assemble the partially approved composite directly.
+		approvals = v7Mutation(header, operators[0], v7.TypeOperatorApprovals, nil)
+		var children v7.OperatorApprovals
+		for _, op := range operators[:step.Approvals] {
+			children = append(children, newApproval(v7Header(approvals), op))
+		}
+		approvals = v7Seal(approvals, children)
+	} else {
+		approvals, err = v7Parallel(header, state, v7.TypeOperatorApprovals, newApproval)
+		if err != nil {
+			return v7.SignedMutation{}, err
+		}
+	}
+
+	return v7Seal(composite, v7.AddValidators{propose, approvals}), nil
+}
+
+// v7Parallel returns the parallel composite built from a child per operator expected by the builder,
+// added in reverse order since the builder orders children to match the operators.
+func v7Parallel(parent v7.Hash, state v7.ClusterState, typ v7.MutationType,
+	newChild func(parent v7.Hash, op v7.PublicKey) v7.SignedMutation,
+) (v7.SignedMutation, error) {
+	if len(state.Operators) == 0 {
+		return v7.SignedMutation{}, fmt.Errorf("no operators")
+	}
+
+	b, err := v7.NewCompositeBuilder(parent, state.Operators[0].PublicKey, typ, state)
+	if err != nil {
+		return v7.SignedMutation{}, err
+	}
+
+	missing := b.Missing()
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := b.Add(newChild(b.Hash(), missing[i])); err != nil {
+			return v7.SignedMutation{}, err
+		}
+	}
+
+	return b.Build()
+}
+
+// v7Mutation returns a signed mutation. Composites are created without data, see v7Seal.
+func v7Mutation(parent v7.Hash, source v7.PublicKey, typ v7.MutationType, data any) v7.SignedMutation {
+	m := v7.Mutation{Parent: parent, Type: typ, Data: data}
+
+	return v7.SignedMutation{
+		Mutation: m,
+		Hash:     m.SignedHash(source),
+		Source:   source,
+	}
+}
+
+// v7Header returns the header hash of the composite which its children reference as their parent.
+func v7Header(composite v7.SignedMutation) v7.Hash {
+	return composite.Mutation.HeaderHash(composite.Source)
+}
+
+// v7Seal returns the composite with the children data and its hash committing to them.
+func v7Seal(composite v7.SignedMutation, data any) v7.SignedMutation {
+	composite.Mutation.Data = data
+	composite.Hash = composite.Mutation.SignedHash(composite.Source)
+
+	return composite
+}
+
+func v7Validators(vals []Validator) v7.Validators {
+	var resp v7.Validators
+	for _, v := range vals {
+		val := v7.Validator{PublicKey: v7.PublicKey(v.PublicKey)}
+		for _, share := range v.PublicShares {
+			val.PublicShares = append(val.PublicShares, v7.PublicKey(share))
+		}
+		resp = append(resp, val)
+	}
+
+	return resp
+}
@@ -0,0 +1,161 @@
+package clusterstate
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fuzzMutation is the JSON encoding of a SignedMutation with data decoded according to its type.
+type fuzzMutation struct {
+	Hash         Hash
+	Source       PublicKey
+	Type         MutationType
+	Data         json.RawMessage
+	ParentHashes []Hash
+	Timestamp    time.Time
+}
+
+func FuzzResolve(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		state, ok := fuzzState(data)
+		if !ok {
+			return
+		}
+
+		_, _ = Resolve(state)
+		_, _ = ResolveReport(state)
+		_, _, _ = ResolveQuarantine(state)
+		_, _ = PendingProposals(state)
+	})
+}
+
+// FuzzValidateAdd validates an untrusted mutation (the last) against an untrusted state.
+func FuzzValidateAdd(f *testing.F) {
+	addFuzzSeeds(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		state, ok := fuzzState(data)
+		if !ok || len(state) == 0 {
+			return
+		}
+
+		sm := state[len(state)-1]
+		_ = ValidateAdd(state[:len(state)-1], sm)
+		_, _, _ = Preview(state[:len(state)-1], sm)
+	})
+}
+
+// addFuzzSeeds adds valid states at each step of a cluster lifecycle and some malformed inputs to the corpus.
+func addFuzzSeeds(f *testing.F) {
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`[{"Type":"charon/create_cluster/1.0.0","Data":{}}]`))
+	f.Add([]byte(`[{"Type":"charon/operator_enr/1.0.0","Data":null,"ParentHashes":[[1]]}]`))
+	f.Add([]byte(`[{"Type":"unknown/1.0.0","Data":{"a":1}}]`))
+
+	state := newFuzzSeedState()
+	for i := 1; i <= len(state); i++ {
+		f.Add(fuzzEncode(f, state[:i]))
+	}
+}
+
+// newFuzzSeedState returns a valid state of a cluster with two operators generating a validator.
+func newFuzzSeedState() State {
+	clock := NewFakeClock(time.Unix(0, 0).UTC())
+	ops := newOperators(2)
+	create := NewSignedMutation(clock, "creator", TypeCreateCluster, newCreateCluster(ops))
+	enr0 := NewSignedMutation(clock, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr://0"}, create)
+	enr1 := NewSignedMutation(clock, ops[1], TypeOperatorENR, OperatorENR{ENR: "enr://1"}, enr0)
+	cluster := Cluster{Operators: []Operator{{PublicKey: ops[0]}, {PublicKey: ops[1]}}, NumValidators: 1}
+	generate := NewSignedMutation(clock, ops[0], TypeGenerateValidators, newGenerateValidators(cluster), enr1)
+	ack0 := NewSignedMutation(clock, ops[0], TypeOperatorAck, OperatorAck{}, generate)
+	ack1 := NewSignedMutation(clock, ops[1], TypeOperatorAck, OperatorAck{}, generate)
+
+	return State{create, enr0, enr1, generate, ack0, ack1}
+}
+
+// fuzzEncode returns the JSON encoding of the state as a list of fuzzMutations.
+func fuzzEncode(tb testing.TB, state State) []byte {
+	var mutations []fuzzMutation
+	for _, sm := range state {
+		data, err := json.Marshal(sm.Mutation.Data)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		mutations = append(mutations, fuzzMutation{
+			Hash:         sm.Hash,
+			Source:       sm.Source,
+			Type:         sm.Mutation.Type,
+			Data:         data,
+			ParentHashes: sm.Mutation.ParentHashes,
+			Timestamp:    sm.Mutation.Timestamp,
+		})
+	}
+
+	b, err := json.Marshal(mutations)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return b
+}
+
+// fuzzState decodes the JSON encoded list of fuzzMutations.
+func fuzzState(data []byte) (State, bool) {
+	var mutations []fuzzMutation
+	if err := json.Unmarshal(data, &mutations); err != nil {
+		return nil, false
+	}
+
+	var resp State
+	for _, m := range mutations {
+		var data any
+		if def, ok := typeDef[m.Type]; ok && def.DataType != nil {
+			ptr := reflect.New(reflect.TypeOf(def.DataType))
+			if err := json.Unmarshal(m.Data, ptr.Interface()); err != nil {
+				return nil, false
+			}
+			data = ptr.Elem().Interface()
+		} else if len(m.Data) > 0 {
+			if err := json.Unmarshal(m.Data, &data); err != nil {
+				return nil, false
+			}
+		}
+
+		resp = append(resp, SignedMutation{
+			Mutation: Mutation{
+				ParentHashes: m.ParentHashes,
+				Type:         m.Type,
+				Data:         data,
+				Timestamp:    m.Timestamp,
+			},
+			Hash:   m.Hash,
+			Source: m.Source,
+		})
+	}
+
+	return resp, true
+}
+
+func TestFuzzSeedState(t *testing.T) {
+	state, ok := fuzzState(fuzzEncode(t, newFuzzSeedState()))
+	if !ok {
+		t.Fatal("failed to decode")
+	}
+
+	// The seed corpus must resolve, otherwise the fuzzer starts from rejected inputs only.
+	clusters, err := Resolve(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var generated bool
+	for _, cluster := range clusters {
+		generated = generated || len(cluster.Validators) == 1
+	}
+	if !generated {
+		t.Fatal("expected a head with the generated validator")
+	}
+}
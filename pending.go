@@ -0,0 +1,75 @@
+package clusterstate
+
+import "errors"
+
+// Proposal represents a mutation that is blocked on approvals.
+type Proposal struct {
+	Mutation SignedMutation
+	// Approvals is the approval policy of the mutation type.
+	Approvals ApprovalPolicy
+	// ApprovedBy are the cluster operators that have approved (built-on) the mutation.
+	ApprovedBy []PublicKey
+	// Missing are the cluster operators that have not approved the mutation yet.
+	Missing []PublicKey
+}
+
+// Pending represents the proposals blocked on approvals at a head of the DAG.
+type Pending struct {
+	// Head is the leaf mutation hash.
+	Head Hash
+	// Cluster is the resolved cluster state at the head, as returned by Resolve.
+	Cluster Cluster
+	// Proposals are the unapproved mutations leading to the head in sequence order.
+	Proposals []Proposal
+}
+
+// PendingProposals returns the proposals blocked on approvals at each head of the DAG.
+// The heads are returned in the same order as Resolve, sorted by head hash.
+func PendingProposals(state State) ([]Pending, error) {
+	var resp []Pending
+	children := state.children()
+	for _, leaf := range state.Leaves() {
+		sequence, err := state.Sequence(leaf)
+		if err != nil {
+			return nil, err
+		}
+
+		// Heads blocked by invalid mutations may still have pending proposals.
+		cluster, applied, err := apply(state, sequence)
+		var merr *MutationError
+		if err != nil && !errors.As(err, &merr) {
+			return nil, err
+		}
+
+		pending := Pending{
+			Head:    leaf,
+			Cluster: cluster,
+		}
+
+		for _, mutation := range sequence[applied:] {
+			approvedBy := children.approvedBy(mutation.Hash)
+			policy := mutation.Mutation.Type.Approvals()
+			if Approved(policy, approvedBy, cluster) {
+				continue // Blocked by a preceding mutation, not by approvals.
+			}
+
+			proposal := Proposal{
+				Mutation:  mutation,
+				Approvals: policy,
+			}
+			for _, op := range cluster.Operators {
+				if approvedBy[op.PublicKey] {
+					proposal.ApprovedBy = append(proposal.ApprovedBy, op.PublicKey)
+				} else {
+					proposal.Missing = append(proposal.Missing, op.PublicKey)
+				}
+			}
+
+			pending.Proposals = append(pending.Proposals, proposal)
+		}
+
+		resp = append(resp, pending)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,67 @@
+package clusterstate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPendingProposals(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(3)
+	create := NewSignedMutation(clock, ops[0], TypeCreateCluster, newCreateCluster(ops))
+	enr0 := NewSignedMutation(clock, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr0"}, create)
+	enr1 := NewSignedMutation(clock, ops[1], TypeOperatorENR, OperatorENR{ENR: "enr1"}, enr0)
+	enr2 := NewSignedMutation(clock, ops[2], TypeOperatorENR, OperatorENR{ENR: "enr2"}, enr1)
+	add := NewSignedMutation(clock, ops[0], TypeAddValidators, AddValidators{NumValidators: 1}, enr2)
+	ack := NewSignedMutation(clock, ops[1], TypeOperatorAck, OperatorAck{}, add)
+	participation := NewSignedMutation(clock, ops[2], TypeParticipationProof, ParticipationProof{EndEpoch: 1}, enr2)
+	state := State{create, enr0, enr1, enr2, add, ack, participation}
+
+	clusters, err := Resolve(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Heads are returned in a stable order matching Resolve.
+	for i := 0; i < 10; i++ {
+		pending, err := PendingProposals(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		leaves := state.Leaves()
+		if len(pending) != 2 || len(leaves) != 2 || len(clusters) != 2 {
+			t.Fatalf("expected 2 heads, got %d", len(pending))
+		}
+
+		for j, p := range pending {
+			if p.Head != leaves[j] {
+				t.Fatal("unexpected head order")
+			} else if !reflect.DeepEqual(p.Cluster, clusters[j]) {
+				t.Fatal("cluster does not match Resolve")
+			}
+
+			switch p.Head {
+			case ack.Hash:
+				if len(p.Proposals) != 1 {
+					t.Fatalf("expected 1 proposal, got %d", len(p.Proposals))
+				}
+				proposal := p.Proposals[0]
+				if proposal.Mutation.Hash != add.Hash || proposal.Approvals != TypeAddValidators.Approvals() {
+					t.Fatal("unexpected proposal")
+				} else if !reflect.DeepEqual(proposal.ApprovedBy, []PublicKey{ops[1]}) {
+					t.Fatalf("unexpected approved by: %v", proposal.ApprovedBy)
+				} else if !reflect.DeepEqual(proposal.Missing, []PublicKey{ops[0], ops[2]}) {
+					t.Fatalf("unexpected missing: %v", proposal.Missing)
+				}
+			case participation.Hash:
+				if len(p.Proposals) != 0 {
+					t.Fatalf("expected no proposals, got %d", len(p.Proposals))
+				}
+			default:
+				t.Fatal("unexpected head")
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package clusterstate
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Change represents a difference in a cluster field between two cluster states.
+// Old is nil for added slice elements and New is nil for removed slice elements.
+type Change struct {
+	Field string
+	Old   any
+	New   any
+}
+
+// Preview returns the cluster that would result if the mutation was added to the state
+// and fully approved, together with the changes relative to the cluster it builds on.
+// Mutations already in the state, like pending proposals, are not validated again.
+//
+// The changes are relative to the cluster resolved from the mutation's parents, not the current head.
+// These are the same when the mutation builds on the head, as proposals do. A mutation building on an
+// older mutation forks the DAG, so mutations after its parents are excluded from both the resulting
+// cluster and the changes.
+func Preview(state State, sm SignedMutation) (Cluster, []Change, error) {
+	next := state
+	if _, _, err := state.Get(sm.Hash); err != nil {
+		if err := ValidateAdd(state, sm); err != nil {
+			return Cluster{}, nil, err
+		}
+
+		next = append(state[:len(state):len(state)], sm)
+	}
+
+	sequence, err := next.Sequence(sm.Hash)
+	if err != nil {
+		return Cluster{}, nil, err
+	}
+
+	// The mutation itself is always last since it has the greatest height in its sequence.
+	parents := sequence[:len(sequence)-1]
+
+	before, _, err := apply(next, parents)
+	if err != nil {
+		return Cluster{}, nil, err
+	}
+
+	after, err := AppendToCluster(sm, before)
+	if err != nil {
+		return Cluster{}, nil, err
+	}
+
+	return after, Diff(before, after), nil
+}
+
+// Diff returns the changes from cluster a to b.
+// The DAG bookkeeping fields Height and Hashes are excluded.
+func Diff(a, b Cluster) []Change {
+	var resp []Change
+	add := func(field string, old, new any) {
+		if !reflect.DeepEqual(old, new) {
+			resp = append(resp, Change{Field: field, Old: old, New: new})
+		}
+	}
+
+	add("ApprovedMutations", a.ApprovedMutations, b.ApprovedMutations)
+	add("Name", a.Name, b.Name)
+	add("Creator", a.Creator, b.Creator)
+
+	for i := 0; i < len(a.Operators) || i < len(b.Operators); i++ {
+		add(fmt.Sprintf("Operators[%d]", i), elem(a.Operators, i), elem(b.Operators, i))
+	}
+
+	add("NumValidators", a.NumValidators, b.NumValidators)
+	add("WithdrawalAddress", a.WithdrawalAddress, b.WithdrawalAddress)
+
+	for i := 0; i < len(a.Validators) || i < len(b.Validators); i++ {
+		add(fmt.Sprintf("Validators[%d]", i), elem(a.Validators, i), elem(b.Validators, i))
+	}
+
+	for i := 0; i < len(a.ParticipationProof) || i < len(b.ParticipationProof); i++ {
+		add(fmt.Sprintf("ParticipationProof[%d]", i), elem(a.ParticipationProof, i), elem(b.ParticipationProof, i))
+	}
+
+	return resp
+}
+
+// elem returns the ith element of the slice or nil if out of bounds.
+func elem[T any](slice []T, i int) any {
+	if i >= len(slice) {
+		return nil
+	}
+
+	return slice[i]
+}
@@ -0,0 +1,174 @@
+package clusterstate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPreviewState returns a state with all operator ENRs and fully approved validators.
+func newPreviewState(clock Clock, ops []PublicKey) (State, Cluster) {
+	create := NewSignedMutation(clock, ops[0], TypeCreateCluster, newCreateCluster(ops))
+	state := State{create}
+	for i, op := range ops {
+		state = append(state, NewSignedMutation(clock, op, TypeOperatorENR, OperatorENR{ENR: "enr://" + string(op)}, state[i]))
+	}
+
+	cluster, err := Resolve(state)
+	if err != nil {
+		panic(err)
+	}
+
+	generate := NewSignedMutation(clock, ops[0], TypeGenerateValidators, newGenerateValidators(cluster[0]), state[len(state)-1])
+	state = append(state, generate)
+	for _, op := range ops {
+		state = append(state, NewSignedMutation(clock, op, TypeOperatorAck, OperatorAck{}, generate))
+	}
+
+	clusters, err := Resolve(state)
+	if err != nil {
+		panic(err)
+	}
+
+	return state, clusters[len(clusters)-1]
+}
+
+func TestPreviewChangeOperators(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(3)
+	state, head := newPreviewState(clock, ops)
+
+	// A pending proposal acked by a single operator.
+	newOps := []PublicKey{ops[0], ops[1], "operator-new"}
+	change := NewSignedMutation(clock, ops[0], TypeChangeOperators, ChangeOperators{NewOperators: newOps}, state[len(state)-1])
+	state = append(state, change, NewSignedMutation(clock, ops[1], TypeOperatorAck, OperatorAck{}, change))
+
+	cluster, changes, err := Preview(state, change)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []PublicKey
+	for _, op := range cluster.Operators {
+		keys = append(keys, op.PublicKey)
+	}
+	if !reflect.DeepEqual(keys, newOps) {
+		t.Fatalf("unexpected operators: %v", keys)
+	}
+
+	expected := []Change{
+		{Field: "ApprovedMutations", Old: head.ApprovedMutations, New: head.ApprovedMutations + 1},
+		{Field: "Operators[0]", Old: head.Operators[0], New: Operator{PublicKey: ops[0]}},
+		{Field: "Operators[1]", Old: head.Operators[1], New: Operator{PublicKey: ops[1]}},
+		{Field: "Operators[2]", Old: head.Operators[2], New: Operator{PublicKey: "operator-new"}},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+}
+
+func TestPreviewReshareValidators(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(3)
+	state, head := newPreviewState(clock, ops)
+
+	reshared := Validator{PublicKey: head.Validators[0].PublicKey, PublicShares: []PublicKey{"a", "b", "c"}}
+	reshare := NewSignedMutation(clock, ops[0], TypeReshareValidators, ReshareValidators{NewValidators: []Validator{reshared}}, state[len(state)-1])
+
+	// The mutation isn't in the state yet, so it is validated and previewed as if added.
+	_, changes, err := Preview(state, reshare)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Change{
+		{Field: "ApprovedMutations", Old: head.ApprovedMutations, New: head.ApprovedMutations + 1},
+		{Field: "Validators[0]", Old: head.Validators[0], New: reshared},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+}
+
+func TestPreviewInvalid(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(3)
+	state, _ := newPreviewState(clock, ops)
+	parent := state[len(state)-1]
+
+	tests := []struct {
+		name     string
+		mutation SignedMutation
+		err      string
+	}{
+		{
+			name:     "unknown parent",
+			mutation: NewSignedMutation(clock, ops[0], TypeChangeOperators, ChangeOperators{NewOperators: ops}, SignedMutation{Hash: Hash{1}}),
+			err:      "not found",
+		},
+		{
+			name:     "invalid parent type",
+			mutation: NewSignedMutation(clock, ops[0], TypeChangeOperators, ChangeOperators{NewOperators: ops}, state[0]),
+			err:      "parent mutation type is not allowed",
+		},
+		{
+			name:     "append fails",
+			mutation: NewSignedMutation(clock, ops[0], TypeChangeOperators, ChangeOperators{NewOperators: ops[:2]}, parent),
+			err:      "invalid change operators",
+		},
+		{
+			name:     "reshare changes validator",
+			mutation: NewSignedMutation(clock, ops[0], TypeReshareValidators, ReshareValidators{NewValidators: []Validator{{PublicKey: "other", PublicShares: ops}}}, parent),
+			err:      "invalid validator",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, _, err := Preview(state, test.mutation); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestPreviewFork(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(3)
+	state, head := newPreviewState(clock, ops)
+
+	// The head advances past the acked validators with a participation proof.
+	parent := state[len(state)-1]
+	proof := NewSignedMutation(clock, ops[0], TypeParticipationProof, newParticipationProof(head), parent)
+	state = append(state, proof)
+
+	clusters, err := Resolve(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, leaf := range state.Leaves() {
+		if leaf == proof.Hash && len(clusters[i].ParticipationProof) != 1 {
+			t.Fatal("expected head with participation proof")
+		}
+	}
+
+	// A mutation building on the head's parent is diffed against its parents, excluding the proof.
+	newOps := []PublicKey{ops[0], ops[1], "operator-new"}
+	change := NewSignedMutation(clock, ops[0], TypeChangeOperators, ChangeOperators{NewOperators: newOps}, parent)
+
+	cluster, changes, err := Preview(state, change)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(cluster.ParticipationProof) != 0 {
+		t.Fatal("expected proof excluded from forked cluster")
+	}
+
+	for _, c := range changes {
+		if strings.HasPrefix(c.Field, "ParticipationProof") {
+			t.Fatalf("expected no participation proof change: %+v", c)
+		}
+	}
+	if len(changes) != 4 {
+		t.Fatalf("expected approved mutations and operator changes only: %+v", changes)
+	}
+}
@@ -0,0 +1,351 @@
+package clusterstate
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestProperties(t *testing.T) {
+	for seed := int64(0); seed < 5; seed++ {
+		t.Run(fmt.Sprint(seed), func(t *testing.T) {
+			conf := propertyConfig{Seed: seed, Iterations: 20}
+			if state, err := checkProperties(conf); err != nil {
+				t.Fatalf("invariant violated by %d mutations: %v", len(state), err)
+			}
+		})
+	}
+}
+
+func TestShrink(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	state := generateDAG(rng, 4, 20)
+
+	// The check fails while the state contains any generate validators mutation.
+	check := func(state State) error {
+		for _, sm := range state {
+			if sm.Mutation.Type == TypeGenerateValidators {
+				return fmt.Errorf("contains generate validators")
+			}
+		}
+
+		return nil
+	}
+	if check(state) == nil {
+		t.Skip("generated DAG without generate validators")
+	}
+
+	shrunk := shrink(state, check)
+	if check(shrunk) == nil {
+		t.Fatal("expected shrunk state to fail the check")
+	}
+
+	// The minimal failing DAG is a single generate validators mutation and its ancestors.
+	if last := shrunk[len(shrunk)-1]; last.Mutation.Type != TypeGenerateValidators {
+		t.Fatalf("expected generate validators last, got %s", last.Mutation.Type)
+	} else if len(shrunk) >= len(state) {
+		t.Fatalf("expected state to shrink: %d >= %d", len(shrunk), len(state))
+	}
+}
+
+func TestGenerateDAGApproved(t *testing.T) {
+	// Full operator approvals must produce approved chains deep enough to exercise the invariants.
+	rng := rand.New(rand.NewSource(0))
+	var deepest int
+	for i := 0; i < 20; i++ {
+		reports, err := resolveSorted(generateDAG(rng, 4, 20))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, report := range reports {
+			if report.Cluster.ApprovedMutations > deepest {
+				deepest = report.Cluster.ApprovedMutations
+			}
+		}
+	}
+
+	if deepest < 3 {
+		t.Fatalf("expected approved chains of at least 3 mutations, got %d", deepest)
+	}
+}
+
+// propertyConfig configures checkProperties.
+type propertyConfig struct {
+	// Seed seeds the random DAG generator.
+	Seed int64
+	// Iterations is the number of random DAGs to check, defaults to 100.
+	Iterations int
+	// NumOperators is the number of cluster operators, defaults to 4.
+	NumOperators int
+	// NumMutations is the target number of mutations per DAG, defaults to 20.
+	NumMutations int
+}
+
+// checkProperties generates random DAGs and checks the invariants of Resolve and ValidateAdd against each.
+// It returns the first failing DAG shrunk to a minimal DAG that still fails, together with the
+// violated invariant. It returns a nil state and error if all DAGs satisfy the invariants.
+func checkProperties(conf propertyConfig) (State, error) {
+	if conf.Iterations <= 0 {
+		conf.Iterations = 100
+	}
+	if conf.NumOperators <= 0 {
+		conf.NumOperators = 4
+	}
+	if conf.NumMutations <= 0 {
+		conf.NumMutations = 20
+	}
+
+	rng := rand.New(rand.NewSource(conf.Seed))
+	for i := 0; i < conf.Iterations; i++ {
+		state := generateDAG(rng, conf.NumOperators, conf.NumMutations)
+
+		// Checks must be deterministic for shrinking, so each uses a rng with the same seed.
+		seed := rng.Int63()
+		check := func(state State) error {
+			return checkInvariants(state, rand.New(rand.NewSource(seed)))
+		}
+
+		if err := check(state); err != nil {
+			state = shrink(state, check)
+			return state, check(state)
+		}
+	}
+
+	return nil, nil
+}
+
+// generateDAG returns a random DAG of about numMutations mutations that are each valid according to ValidateAdd.
+func generateDAG(rng *rand.Rand, numOperators, numMutations int) State {
+	var (
+		clock     = NewFakeClock(time.Unix(0, 0).UTC())
+		operators = newOperators(numOperators)
+		state     = State{NewSignedMutation(clock, "creator", TypeCreateCluster, newCreateCluster(operators))}
+		proofs    int
+	)
+
+	for attempt := 0; len(state) < numMutations && attempt < numMutations*20; attempt++ {
+		clock.Advance(time.Second)
+
+		source := operators[rng.Intn(len(operators))]
+
+		var (
+			typ  MutationType
+			data any
+		)
+		switch rng.Intn(6) {
+		case 0:
+			typ, data = TypeOperatorENR, OperatorENR{ENR: fmt.Sprintf("enr://%s", source)}
+		case 1:
+			cluster := Cluster{NumValidators: 1}
+			for _, op := range operators {
+				cluster.Operators = append(cluster.Operators, Operator{PublicKey: op})
+			}
+			typ, data = TypeGenerateValidators, newGenerateValidators(cluster)
+		case 2:
+			typ, data = TypeAddValidators, AddValidators{NumValidators: 1}
+		case 3:
+			typ, data = TypeOperatorAck, OperatorAck{}
+		case 4:
+			ops := append([]PublicKey(nil), operators...)
+			rng.Shuffle(len(ops), func(i, j int) { ops[i], ops[j] = ops[j], ops[i] })
+			typ, data = TypeChangeOperators, ChangeOperators{NewOperators: ops}
+		default:
+			typ, data = TypeParticipationProof, ParticipationProof{StartEpoch: proofs * 10, EndEpoch: proofs*10 + 9}
+		}
+
+		// Prefer extending the heads so that long approved chains occur.
+		parents := []SignedMutation{state[rng.Intn(len(state))]}
+		if leaves := state.Leaves(); rng.Intn(2) == 0 {
+			parent, _, _ := state.Get(leaves[rng.Intn(len(leaves))])
+			parents[0] = parent
+		}
+		if !isNoApprovals(typ.Approvals()) && rng.Intn(3) == 0 {
+			parents = append(parents, state[rng.Intn(len(state))])
+		}
+
+		sm := NewSignedMutation(clock, source, typ, data, parents...)
+		if err := ValidateAdd(state, sm); err != nil {
+			continue
+		}
+
+		if typ == TypeParticipationProof {
+			proofs++
+		}
+
+		state = append(state, sm)
+
+		// Most proposals are approved by all operators, the rest remain pending or are acked randomly.
+		if isNoApprovals(typ.Approvals()) || rng.Intn(4) == 0 {
+			continue
+		}
+		for _, op := range operators {
+			ack := NewSignedMutation(clock, op, TypeOperatorAck, OperatorAck{}, sm)
+			if err := ValidateAdd(state, ack); err == nil {
+				state = append(state, ack)
+			}
+		}
+	}
+
+	return state
+}
+
+// checkInvariants returns an error if the state violates any of the following invariants:
+//   - Resolve succeeds.
+//   - Resolve and ValidateAdd results are independent of the order of the state.
+//   - Every head's Cluster.Hashes is closed under parents.
+//   - ApprovedMutations never decreases along a path.
+//   - Every validator has a public share per operator.
+func checkInvariants(state State, rng *rand.Rand) error {
+	shuffled := append(State(nil), state...)
+	rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	expect, expectErr := resolveSorted(state)
+	actual, actualErr := resolveSorted(shuffled)
+	if (expectErr == nil) != (actualErr == nil) {
+		return fmt.Errorf("resolve error depends on state order: %v != %v", expectErr, actualErr)
+	} else if expectErr != nil {
+		// Generated DAGs and their shrunk sub-DAGs are valid by construction, so they must resolve.
+		return fmt.Errorf("resolve: %w", expectErr)
+	} else if len(expect) != len(actual) {
+		return fmt.Errorf("resolved heads depend on state order")
+	}
+
+	for i := range expect {
+		if expect[i].Head != actual[i].Head || len(Diff(expect[i].Cluster, actual[i].Cluster)) > 0 ||
+			expect[i].Cluster.Height != actual[i].Cluster.Height {
+			return fmt.Errorf("resolved cluster depends on state order: head %x", expect[i].Head[:4])
+		}
+
+		cluster := expect[i].Cluster
+		for hash, sm := range cluster.Hashes {
+			for _, p := range sm.Mutation.ParentHashes {
+				if _, ok := cluster.Hashes[p]; !ok {
+					return fmt.Errorf("cluster hashes not closed under parents: head %x, mutation %x", expect[i].Head[:4], hash[:4])
+				}
+			}
+		}
+
+		for _, v := range cluster.Validators {
+			if len(v.PublicShares) != len(cluster.Operators) {
+				return fmt.Errorf("validator shares do not match operators: head %x, validator %s", expect[i].Head[:4], v.PublicKey)
+			}
+		}
+	}
+
+	// Check ApprovedMutations along all edges by resolving the cluster at every mutation.
+	approved := make(map[Hash]int)
+	for _, sm := range state {
+		sequence, err := state.Sequence(sm.Hash)
+		if err != nil {
+			return err
+		}
+
+		cluster, _, _ := apply(state, sequence)
+		approved[sm.Hash] = cluster.ApprovedMutations
+	}
+	for _, sm := range state {
+		for _, p := range sm.Mutation.ParentHashes {
+			if approved[sm.Hash] < approved[p] {
+				return fmt.Errorf("approved mutations decreased from %x to %x", p[:4], sm.Hash[:4])
+			}
+		}
+	}
+
+	// Check ValidateAdd of each leaf against the rest of the state in both orders.
+	for _, leaf := range state.Leaves() {
+		sm, _, err := state.Get(leaf)
+		if err != nil || sm.Mutation.Type == TypeCreateCluster {
+			continue
+		}
+
+		expectErr := ValidateAdd(without(state, leaf), sm)
+		actualErr := ValidateAdd(without(shuffled, leaf), sm)
+		if (expectErr == nil) != (actualErr == nil) {
+			return fmt.Errorf("validate add depends on state order: %v != %v", expectErr, actualErr)
+		}
+	}
+
+	return nil
+}
+
+// shrink returns a minimal sub-DAG of the state that still fails the check.
+// It greedily removes mutations together with their descendants.
+func shrink(state State, check func(State) error) State {
+	for {
+		var shrunk bool
+		for i := len(state) - 1; i > 0; i-- {
+			candidate := withoutDescendants(state, state[i].Hash)
+			if check(candidate) != nil {
+				state = candidate
+				shrunk = true
+
+				break
+			}
+		}
+
+		if !shrunk {
+			return state
+		}
+	}
+}
+
+// resolveSorted returns the head reports sorted by head hash.
+func resolveSorted(state State) ([]HeadReport, error) {
+	reports, err := ResolveReport(state)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return bytes.Compare(reports[i].Head[:], reports[j].Head[:]) < 0
+	})
+
+	return reports, nil
+}
+
+// without returns the state without the mutation.
+func without(state State, hash Hash) State {
+	var resp State
+	for _, sm := range state {
+		if sm.Hash != hash {
+			resp = append(resp, sm)
+		}
+	}
+
+	return resp
+}
+
+// withoutDescendants returns the state without the mutation and all its descendants.
+func withoutDescendants(state State, hash Hash) State {
+	removed := map[Hash]bool{hash: true}
+	for changed := true; changed; {
+		changed = false
+		for _, sm := range state {
+			if removed[sm.Hash] {
+				continue
+			}
+
+			for _, p := range sm.Mutation.ParentHashes {
+				if removed[p] {
+					removed[sm.Hash] = true
+					changed = true
+
+					break
+				}
+			}
+		}
+	}
+
+	var resp State
+	for _, sm := range state {
+		if !removed[sm.Hash] {
+			resp = append(resp, sm)
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,194 @@
+package clusterstate
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrQuarantinedAncestor is the reason of a MutationError for mutations that depend on a quarantined mutation.
+	ErrQuarantinedAncestor = errors.New("depends on quarantined mutation")
+	// ErrNotCreateCluster is the reason of a MutationError for mutations without parents that do not create the cluster.
+	ErrNotCreateCluster = errors.New("mutation without parents is not create cluster")
+	// ErrDuplicateHash is the reason of a MutationError for different mutations with the same hash.
+	ErrDuplicateHash = errors.New("duplicate mutation hash")
+	// ErrUnknownParent is the reason of a MutationError for mutations with a parent not in the state.
+	ErrUnknownParent = errors.New("unknown parent mutation")
+	// ErrCycle is the reason of a MutationError for mutations in or depending on a cycle of parents.
+	ErrCycle = errors.New("mutation in or depending on a parent cycle")
+)
+
+// ResolveQuarantine returns the resulting cluster state at all heads/forks like Resolve,
+// but instead of failing, it quarantines (skips) mutations that cannot be appended to
+// the cluster and all mutations that depend on them. Mutations that cannot be sequenced, since
+// different mutations have the same hash or their parents are unknown or cyclic, are quarantined first
+// in state order, identical copies of a mutation are ignored. The others are returned in sequence order, each with the reason it was quarantined.
+// Heads without a valid create cluster mutation are not returned, their mutations are quarantined instead.
+// It assumes that fork choice has already been applied to state.
+func ResolveQuarantine(state State) ([]Cluster, []*MutationError, error) {
+	if len(state) == 0 {
+		return nil, nil, fmt.Errorf("empty state")
+	}
+
+	state, quarantined := sanitise(state)
+	if len(state) == 0 {
+		return nil, quarantined, nil
+	}
+
+	var (
+		resp     []Cluster
+		dedup    = make(map[Hash]bool)
+		children = state.children()
+	)
+	for _, leaf := range state.Leaves() {
+		sequence, err := state.Sequence(leaf)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Quarantine is tracked per head, since the validity of a mutation depends on the sequence it is appended in.
+		skip := make(map[Hash]bool)
+		quarantine := func(mutation SignedMutation, reason error) {
+			skip[mutation.Hash] = true
+			if dedup[mutation.Hash] {
+				return
+			}
+			dedup[mutation.Hash] = true
+			quarantined = append(quarantined, newMutationError(mutation, reason))
+		}
+
+		var cluster Cluster
+		for _, mutation := range sequence {
+			// Sequences are topologically sorted, so checking direct parents is sufficient.
+			var skipParent bool
+			for _, parent := range mutation.Mutation.ParentHashes {
+				skipParent = skipParent || skip[parent]
+			}
+			if skipParent {
+				quarantine(mutation, ErrQuarantinedAncestor)
+				continue
+			}
+
+			if len(mutation.Mutation.ParentHashes) == 0 && mutation.Mutation.Type != TypeCreateCluster {
+				quarantine(mutation, ErrNotCreateCluster)
+				continue
+			}
+
+			policy := mutation.Mutation.Type.Approvals()
+			if !isNoApprovals(policy) && !Approved(policy, children.approvedBy(mutation.Hash), cluster) {
+				break
+			}
+
+			next, err := AppendToCluster(mutation, cluster)
+			if err != nil {
+				quarantine(mutation, err)
+				continue
+			}
+
+			cluster = next
+		}
+
+		if cluster.Height == 0 {
+			continue // Nothing could be applied, so the head has no cluster.
+		}
+
+		resp = append(resp, cluster)
+	}
+
+	return resp, quarantined, nil
+}
+
+// sanitise returns the mutations of the state that can be sequenced, excluding identical copies,
+// and the quarantined mutations that cannot, in state order.
+func sanitise(state State) (State, []*MutationError) {
+	var (
+		reasons = make(map[int]error)
+		indexes = make(map[Hash]int)
+		copies  = make(map[int]bool)
+	)
+	for i, sm := range state {
+		j, ok := indexes[sm.Hash]
+		if !ok {
+			indexes[sm.Hash] = i
+		} else if sm.Mutation.Hash(sm.Source) == state[j].Mutation.Hash(state[j].Source) {
+			copies[i] = true
+		} else {
+			// Different mutations with the same hash are all quarantined, since it's unknown which is referenced.
+			reasons[i], reasons[j] = ErrDuplicateHash, ErrDuplicateHash
+		}
+	}
+
+	// Quarantine mutations with unknown or quarantined parents until no more are found.
+	for changed := true; changed; {
+		changed = false
+		for i, sm := range state {
+			if reasons[i] != nil || copies[i] {
+				continue
+			}
+
+			for _, p := range sm.Mutation.ParentHashes {
+				if j, ok := indexes[p]; !ok {
+					reasons[i] = ErrUnknownParent
+				} else if reasons[j] != nil {
+					reasons[i] = ErrQuarantinedAncestor
+				} else {
+					continue
+				}
+				changed = true
+
+				break
+			}
+		}
+	}
+
+	// Remaining mutations that cannot be sorted topologically are in or depend on a cycle.
+	var (
+		pending  = make(map[int]int)
+		children = make(map[int][]int)
+		ready    []int
+	)
+	for i, sm := range state {
+		if reasons[i] != nil || copies[i] {
+			continue
+		}
+
+		for _, p := range sm.Mutation.ParentHashes {
+			children[indexes[p]] = append(children[indexes[p]], i)
+		}
+		pending[i] = len(sm.Mutation.ParentHashes)
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		delete(pending, i)
+
+		for _, child := range children[i] {
+			pending[child]--
+			if pending[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+	for i := range pending {
+		reasons[i] = ErrCycle
+	}
+
+	var (
+		resp        State
+		quarantined []*MutationError
+	)
+	for i, sm := range state {
+		if reasons[i] != nil {
+			quarantined = append(quarantined, newMutationError(sm, reasons[i]))
+			continue
+		} else if copies[i] {
+			continue
+		}
+		resp = append(resp, sm)
+	}
+
+	return resp, quarantined
+}
@@ -0,0 +1,182 @@
+package clusterstate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolveQuarantine(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(3)
+	create := NewSignedMutation(clock, ops[0], TypeCreateCluster, newCreateCluster(ops))
+	enr0 := NewSignedMutation(clock, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr0"}, create)
+	invalid := NewSignedMutation(clock, ops[1], TypeParticipationProof, ParticipationProof{StartEpoch: 2, EndEpoch: 1}, enr0)
+	dependent := NewSignedMutation(clock, ops[2], TypeParticipationProof, ParticipationProof{StartEpoch: 3, EndEpoch: 4}, invalid)
+
+	// A faulty operator adds a mutation without parents, creating a head without a create cluster mutation.
+	orphan := NewSignedMutation(clock, ops[1], TypeOperatorENR, OperatorENR{ENR: "enr1"})
+	orphanChild := NewSignedMutation(clock, ops[2], TypeOperatorENR, OperatorENR{ENR: "enr2"}, orphan)
+
+	if err := ValidateAdd(State{create, enr0}, orphan); err == nil || !strings.Contains(err.Error(), "without parents") {
+		t.Fatalf("expected orphan rejected, got %v", err)
+	}
+
+	state := State{create, enr0, invalid, dependent, orphan, orphanChild}
+
+	if _, err := Resolve(state); err == nil {
+		t.Fatal("expected resolve error")
+	}
+
+	clusters, quarantined, err := ResolveQuarantine(state)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got %d", len(clusters))
+	} else if clusters[0].Height != 2 || clusters[0].Operators[0].ENR != "enr0" {
+		t.Fatalf("unexpected cluster: %+v", clusters[0])
+	}
+
+	reasons := make(map[Hash]error)
+	for _, q := range quarantined {
+		reasons[q.Hash] = q.Reason
+	}
+	if len(reasons) != 4 {
+		t.Fatalf("expected 4 quarantined mutations, got %d", len(reasons))
+	} else if reasons[invalid.Hash] == nil || errors.Is(reasons[invalid.Hash], ErrQuarantinedAncestor) {
+		t.Fatalf("unexpected invalid reason: %v", reasons[invalid.Hash])
+	} else if !errors.Is(reasons[dependent.Hash], ErrQuarantinedAncestor) {
+		t.Fatalf("unexpected dependent reason: %v", reasons[dependent.Hash])
+	} else if !errors.Is(reasons[orphan.Hash], ErrNotCreateCluster) {
+		t.Fatalf("unexpected orphan reason: %v", reasons[orphan.Hash])
+	} else if !errors.Is(reasons[orphanChild.Hash], ErrQuarantinedAncestor) {
+		t.Fatalf("unexpected orphan child reason: %v", reasons[orphanChild.Hash])
+	}
+}
+
+func TestValidateAddHash(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(2)
+	create := NewSignedMutation(clock, ops[0], TypeCreateCluster, newCreateCluster(ops))
+	state := State{create}
+
+	arbitrary := NewSignedMutation(clock, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr0"}, create)
+	arbitrary.Hash = Hash{1}
+
+	parentHash := NewSignedMutation(clock, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr0"}, create)
+	parentHash.Hash = create.Hash
+
+	forgedCreate := create
+	forgedCreate.Source = ops[1]
+
+	for _, sm := range []SignedMutation{arbitrary, parentHash} {
+		if err := ValidateAdd(state, sm); err == nil || !strings.Contains(err.Error(), "invalid mutation hash") {
+			t.Fatalf("expected invalid hash error, got %v", err)
+		}
+	}
+	if err := ValidateAdd(nil, forgedCreate); err == nil || !strings.Contains(err.Error(), "invalid mutation hash") {
+		t.Fatalf("expected invalid hash error, got %v", err)
+	}
+}
+
+func TestResolveQuarantineMalformed(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(3)
+	create := NewSignedMutation(clock, ops[0], TypeCreateCluster, newCreateCluster(ops))
+	enr0 := NewSignedMutation(clock, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr0"}, create)
+
+	// withHash returns the mutation with a forged hash.
+	withHash := func(sm SignedMutation, hash Hash) SignedMutation {
+		sm.Hash = hash
+		return sm
+	}
+
+	selfParent := withHash(SignedMutation{Mutation: Mutation{ParentHashes: []Hash{{1}}, Type: TypeOperatorENR, Data: OperatorENR{}}, Source: ops[1]}, Hash{1})
+	cycleA := withHash(SignedMutation{Mutation: Mutation{ParentHashes: []Hash{{3}}, Type: TypeOperatorENR, Data: OperatorENR{}}, Source: ops[1]}, Hash{2})
+	cycleB := withHash(SignedMutation{Mutation: Mutation{ParentHashes: []Hash{{2}}, Type: TypeOperatorENR, Data: OperatorENR{}}, Source: ops[2]}, Hash{3})
+	dangling := NewSignedMutation(clock, ops[1], TypeOperatorENR, OperatorENR{ENR: "enr1"}, SignedMutation{Hash: Hash{9}})
+	danglingChild := NewSignedMutation(clock, ops[2], TypeOperatorENR, OperatorENR{ENR: "enr2"}, dangling)
+	collision := withHash(NewSignedMutation(clock, ops[1], TypeOperatorENR, OperatorENR{ENR: "enr1"}, enr0), enr0.Hash)
+
+	tests := []struct {
+		name     string
+		state    State
+		height   int // Height of the single resolved cluster, zero if none.
+		expected map[Hash]error
+	}{
+		{
+			name:     "self parent",
+			state:    State{create, enr0, selfParent},
+			height:   2,
+			expected: map[Hash]error{selfParent.Hash: ErrCycle},
+		},
+		{
+			name:     "cycle",
+			state:    State{create, enr0, cycleA, cycleB},
+			height:   2,
+			expected: map[Hash]error{cycleA.Hash: ErrCycle, cycleB.Hash: ErrCycle},
+		},
+		{
+			name:     "dangling parent",
+			state:    State{create, enr0, dangling, danglingChild},
+			height:   2,
+			expected: map[Hash]error{dangling.Hash: ErrUnknownParent, danglingChild.Hash: ErrQuarantinedAncestor},
+		},
+		{
+			// Both mutations with the hash are quarantined, since it's unknown which one children reference.
+			name:     "hash equal to parent",
+			state:    State{create, enr0, collision},
+			height:   1,
+			expected: map[Hash]error{enr0.Hash: ErrDuplicateHash},
+		},
+		{
+			name:     "identical copy",
+			state:    State{create, enr0, enr0},
+			height:   2,
+			expected: map[Hash]error{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The result is independent of state order.
+			for _, state := range []State{test.state, reversed(test.state)} {
+				clusters, quarantined, err := ResolveQuarantine(state)
+				if err != nil {
+					t.Fatal(err)
+				} else if len(clusters) != 1 || clusters[0].Height != test.height {
+					t.Fatalf("expected a cluster of height %d, got %+v", test.height, clusters)
+				}
+
+				reasons := make(map[Hash]error)
+				for _, q := range quarantined {
+					if reasons[q.Hash] != nil && !errors.Is(q.Reason, reasons[q.Hash]) {
+						t.Fatalf("conflicting reasons for %x", q.Hash)
+					}
+					reasons[q.Hash] = q.Reason
+				}
+				if len(reasons) != len(test.expected) {
+					t.Fatalf("expected %d quarantined, got %v", len(test.expected), quarantined)
+				}
+				for hash, reason := range test.expected {
+					if !errors.Is(reasons[hash], reason) {
+						t.Fatalf("expected %v, got %v", reason, reasons[hash])
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := Resolve(State{create, withHash(enr0, create.Hash)}); err == nil {
+		t.Fatal("expected resolve error for cyclic hashes")
+	}
+}
+
+func reversed(state State) State {
+	var resp State
+	for i := len(state) - 1; i >= 0; i-- {
+		resp = append(resp, state[i])
+	}
+
+	return resp
+}
@@ -0,0 +1,85 @@
+package clusterstate
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TypeDef defines a mutation type.
+type TypeDef struct {
+	// Approvals is the policy that must be satisfied before the mutation is applied.
+	Approvals ApprovalPolicy
+	// DataType is the zero value of the mutation data.
+	DataType any
+	// ParentTypes are the mutation types this type may build on.
+	ParentTypes []MutationType
+	// ChildTypes are existing mutation types that may build on this type.
+	// It allows custom types to be inserted into the DAG without changing builtin definitions.
+	ChildTypes []MutationType
+	// AppendFunc appends the mutation to the cluster, returning the new cluster state.
+	AppendFunc func(SignedMutation, Cluster) (Cluster, error)
+	// ValidateFunc optionally validates that the mutation can be added to the state.
+	ValidateFunc func(State, SignedMutation) error
+	// UpgradeFunc optionally translates the mutation to a newer version of the same type.
+	// Mutations are upgraded to the latest version before being appended to the cluster.
+	UpgradeFunc func(Mutation) (Mutation, error)
+}
+
+// RegisterMutationType registers a custom mutation type.
+// It returns an error if the type conflicts with a registered version of the same name.
+// It is not safe for concurrent use and should be called during program initialisation.
+func RegisterMutationType(typ MutationType, def TypeDef) error {
+	if _, _, err := parseType(typ); err != nil {
+		return err
+	} else if _, ok := typeDef[typ]; ok {
+		return fmt.Errorf("mutation type already registered: %s", typ)
+	} else if def.AppendFunc == nil {
+		return fmt.Errorf("mutation type missing append func: %s", typ)
+	}
+
+	if err := verifyVersionConflicts(typ, def); err != nil {
+		return err
+	}
+
+	for _, p := range append(def.ParentTypes, def.ChildTypes...) {
+		if _, ok := typeDef[p]; !ok && p != typ {
+			return fmt.Errorf("unknown related mutation type: %s", p)
+		}
+	}
+
+	typeDef[typ] = def
+
+	return nil
+}
+
+// verifyVersionConflicts returns an error if the type conflicts with registered versions of the same name.
+// Versions differing only by patch must have the same data type, and versions older than the
+// latest must be upgradable to it, whether they are registered before or after the newer version.
+func verifyVersionConflicts(typ MutationType, def TypeDef) error {
+	_, version, err := parseType(typ)
+	if err != nil {
+		return err
+	}
+
+	for other, otherDef := range typeDef {
+		if other.Name() != typ.Name() {
+			continue
+		}
+
+		otherVersion, err := other.Version()
+		if err != nil {
+			return err
+		}
+
+		if otherVersion.Major == version.Major && otherVersion.Minor == version.Minor &&
+			reflect.TypeOf(otherDef.DataType) != reflect.TypeOf(def.DataType) {
+			return fmt.Errorf("mutation type data type conflicts with %s: %s", other, typ)
+		} else if version.Less(otherVersion) && def.UpgradeFunc == nil {
+			return fmt.Errorf("mutation type older than %s missing upgrade func: %s", other, typ)
+		} else if otherVersion.Less(version) && otherDef.UpgradeFunc == nil {
+			return fmt.Errorf("mutation type newer than %s which is missing upgrade func: %s", other, typ)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package clusterstate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterMutationType(t *testing.T) {
+	type dataV1 struct{ A int }
+	type dataV2 struct{ A, B int }
+
+	appendFunc := func(_ SignedMutation, c Cluster) (Cluster, error) {
+		return c, nil
+	}
+	upgradeFunc := func(m Mutation) (Mutation, error) {
+		return m, nil
+	}
+
+	register := func(t *testing.T, typ MutationType, def TypeDef) error {
+		t.Helper()
+		err := RegisterMutationType(typ, def)
+		if err == nil {
+			t.Cleanup(func() { delete(typeDef, typ) })
+		}
+
+		return err
+	}
+
+	tests := []struct {
+		name     string
+		existing map[MutationType]TypeDef
+		typ      MutationType
+		def      TypeDef
+		err      string
+	}{
+		{
+			name: "valid",
+			typ:  "test/metadata/1.0.0",
+			def:  TypeDef{DataType: dataV1{}, ParentTypes: []MutationType{TypeOperatorAck}, AppendFunc: appendFunc},
+		},
+		{
+			name: "invalid name",
+			typ:  "metadata/1.0.0",
+			def:  TypeDef{AppendFunc: appendFunc},
+			err:  "invalid mutation type",
+		},
+		{
+			name: "invalid version",
+			typ:  "test/metadata/1.0",
+			def:  TypeDef{AppendFunc: appendFunc},
+			err:  "invalid version",
+		},
+		{
+			name: "builtin duplicate",
+			typ:  TypeCreateCluster,
+			def:  TypeDef{AppendFunc: appendFunc},
+			err:  "already registered",
+		},
+		{
+			name: "missing append func",
+			typ:  "test/metadata/1.0.0",
+			def:  TypeDef{DataType: dataV1{}},
+			err:  "missing append func",
+		},
+		{
+			name: "unknown parent",
+			typ:  "test/metadata/1.0.0",
+			def:  TypeDef{ParentTypes: []MutationType{"test/unknown/1.0.0"}, AppendFunc: appendFunc},
+			err:  "unknown related mutation type",
+		},
+		{
+			name:     "patch with same data type",
+			existing: map[MutationType]TypeDef{"test/metadata/1.0.0": {DataType: dataV1{}, AppendFunc: appendFunc, UpgradeFunc: upgradeFunc}},
+			typ:      "test/metadata/1.0.1",
+			def:      TypeDef{DataType: dataV1{}, AppendFunc: appendFunc},
+		},
+		{
+			name:     "patch with different data type",
+			existing: map[MutationType]TypeDef{"test/metadata/1.0.0": {DataType: dataV1{}, AppendFunc: appendFunc}},
+			typ:      "test/metadata/1.0.1",
+			def:      TypeDef{DataType: dataV2{}, AppendFunc: appendFunc},
+			err:      "data type conflicts",
+		},
+		{
+			name:     "minor with different data type",
+			existing: map[MutationType]TypeDef{"test/metadata/1.0.0": {DataType: dataV1{}, AppendFunc: appendFunc, UpgradeFunc: upgradeFunc}},
+			typ:      "test/metadata/1.1.0",
+			def:      TypeDef{DataType: dataV2{}, AppendFunc: appendFunc},
+		},
+		{
+			name:     "older without upgrade func",
+			existing: map[MutationType]TypeDef{"test/metadata/2.0.0": {DataType: dataV2{}, AppendFunc: appendFunc}},
+			typ:      "test/metadata/1.0.0",
+			def:      TypeDef{DataType: dataV1{}, AppendFunc: appendFunc},
+			err:      "missing upgrade func",
+		},
+		{
+			name:     "newer than version without upgrade func",
+			existing: map[MutationType]TypeDef{"test/metadata/1.0.0": {DataType: dataV1{}, AppendFunc: appendFunc}},
+			typ:      "test/metadata/2.0.0",
+			def:      TypeDef{DataType: dataV2{}, AppendFunc: appendFunc},
+			err:      "missing upgrade func",
+		},
+		{
+			name: "newer than builtin without upgrade func",
+			typ:  "charon/add_validators/2.0.0",
+			def:  TypeDef{Approvals: ApprovalsNone, DataType: AddValidators{}, AppendFunc: appendFunc},
+			err:  "missing upgrade func",
+		},
+		{
+			name:     "older with upgrade func",
+			existing: map[MutationType]TypeDef{"test/metadata/2.0.0": {DataType: dataV2{}, AppendFunc: appendFunc}},
+			typ:      "test/metadata/1.0.0",
+			def:      TypeDef{DataType: dataV1{}, AppendFunc: appendFunc, UpgradeFunc: upgradeFunc},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for typ, def := range test.existing {
+				if err := register(t, typ, def); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := register(t, test.typ, test.def)
+			if test.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
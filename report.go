@@ -0,0 +1,79 @@
+package clusterstate
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotApproved is the reason of a MutationError for mutations whose approval policy is not satisfied.
+var ErrNotApproved = errors.New("mutation not approved")
+
+// MutationError is returned when a mutation cannot be applied to the cluster.
+type MutationError struct {
+	Hash   Hash
+	Type   MutationType
+	Source PublicKey
+	Reason error
+}
+
+func newMutationError(sm SignedMutation, reason error) *MutationError {
+	return &MutationError{
+		Hash:   sm.Hash,
+		Type:   sm.Mutation.Type,
+		Source: sm.Source,
+		Reason: reason,
+	}
+}
+
+func (e *MutationError) Error() string {
+	return fmt.Sprintf("mutation %x (type=%s, source=%s): %v", e.Hash[:], e.Type, e.Source, e.Reason)
+}
+
+func (e *MutationError) Unwrap() error {
+	return e.Reason
+}
+
+// HeadReport represents the resolution of a head of the DAG.
+type HeadReport struct {
+	// Head is the leaf mutation hash.
+	Head Hash
+	// Cluster is the resolved cluster state at the head.
+	Cluster Cluster
+	// Applied is the sequence of mutations appended to the cluster.
+	Applied []SignedMutation
+	// Blocked is the first mutation that could not be applied and why, or nil if all mutations were applied.
+	Blocked *MutationError
+}
+
+// ResolveReport returns the resolution of each head of the DAG sorted by head hash, the same order as Resolve.
+// Unlike Resolve, it doesn't return an error for mutations that cannot be appended,
+// these are reported as blocked instead.
+func ResolveReport(state State) ([]HeadReport, error) {
+	if len(state) == 0 {
+		return nil, fmt.Errorf("empty state")
+	}
+
+	var resp []HeadReport
+	for _, leaf := range state.Leaves() {
+		sequence, err := state.Sequence(leaf)
+		if err != nil {
+			return nil, err
+		}
+
+		cluster, applied, err := apply(state, sequence)
+
+		var blocked *MutationError
+		if err != nil && !errors.As(err, &blocked) {
+			return nil, err
+		}
+
+		resp = append(resp, HeadReport{
+			Head:    leaf,
+			Cluster: cluster,
+			Applied: sequence[:applied],
+			Blocked: blocked,
+		})
+	}
+
+	return resp, nil
+}
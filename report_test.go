@@ -0,0 +1,59 @@
+package clusterstate
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResolveReport(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(3)
+	create := NewSignedMutation(clock, ops[0], TypeCreateCluster, newCreateCluster(ops))
+	enr0 := NewSignedMutation(clock, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr0"}, create)
+	enr1 := NewSignedMutation(clock, ops[1], TypeOperatorENR, OperatorENR{ENR: "enr1"}, enr0)
+	enr2 := NewSignedMutation(clock, ops[2], TypeOperatorENR, OperatorENR{ENR: "enr2"}, enr1)
+	add := NewSignedMutation(clock, ops[0], TypeAddValidators, AddValidators{NumValidators: 1}, enr2)
+	invalid := NewSignedMutation(clock, ops[1], TypeParticipationProof, ParticipationProof{StartEpoch: 2, EndEpoch: 1}, enr2)
+	state := State{create, enr0, enr1, enr2, add, invalid}
+
+	if _, err := Resolve(state); err == nil {
+		t.Fatal("expected resolve error")
+	}
+
+	reports, err := ResolveReport(state)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+
+	leaves := state.Leaves()
+	for i, report := range reports {
+		if report.Head != leaves[i] {
+			t.Fatal("unexpected head order")
+		} else if len(report.Applied) != 4 || report.Cluster.Height != 4 {
+			t.Fatalf("expected 4 applied mutations, got %d", len(report.Applied))
+		} else if report.Blocked == nil || report.Blocked.Hash != report.Head {
+			t.Fatal("expected head blocked")
+		}
+
+		var merr *MutationError
+		if !errors.As(error(report.Blocked), &merr) {
+			t.Fatal("expected mutation error")
+		}
+
+		switch report.Head {
+		case add.Hash:
+			if !errors.Is(report.Blocked, ErrNotApproved) || merr.Type != TypeAddValidators || merr.Source != ops[0] {
+				t.Fatalf("unexpected blocked reason: %v", report.Blocked)
+			}
+		case invalid.Hash:
+			if errors.Is(report.Blocked, ErrNotApproved) || merr.Type != TypeParticipationProof || merr.Source != ops[1] {
+				t.Fatalf("unexpected blocked reason: %v", report.Blocked)
+			}
+		default:
+			t.Fatal("unexpected head")
+		}
+	}
+}
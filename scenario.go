@@ -0,0 +1,242 @@
+package clusterstate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Scenario is a declarative DAG test case, see testdata/scenarios for examples.
+type Scenario struct {
+	Name  string         `json:"name"`
+	Steps []ScenarioStep `json:"steps"`
+	// Resolve is the expected result of resolving the final state.
+	Resolve ScenarioResolve `json:"resolve"`
+}
+
+// ScenarioStep adds a mutation to the state.
+type ScenarioStep struct {
+	// Label identifies the mutation for use as a parent of subsequent steps and expected heads.
+	Label   string          `json:"label"`
+	Source  PublicKey       `json:"source"`
+	Type    MutationType    `json:"type"`
+	Data    json.RawMessage `json:"data"`
+	Parents []string        `json:"parents"`
+	// Error is the expected ValidateAddWithClock error substring, the mutation is only added if empty.
+	Error string `json:"error"`
+	// Force adds the mutation without validating it.
+	Force bool `json:"force"`
+}
+
+// ScenarioResolve is the expected result of Resolve.
+type ScenarioResolve struct {
+	// Error is the expected error substring.
+	Error string `json:"error"`
+	// Heads are the expected clusters by head label, heads not included are not checked.
+	Heads map[string]ScenarioCluster `json:"heads"`
+}
+
+// ScenarioCluster is the expected cluster at a head, omitted fields are not checked.
+type ScenarioCluster struct {
+	ApprovedMutations *int        `json:"approved_mutations"`
+	Name              *string     `json:"name"`
+	Operators         []PublicKey `json:"operators"`
+	ENRs              *int        `json:"enrs"`
+	NumValidators     *int        `json:"num_validators"`
+	Validators        *int        `json:"validators"`
+}
+
+// LoadScenario returns the scenario in the JSON file.
+// Unknown fields are rejected so that misspelt expectations are not silently skipped.
+func LoadScenario(file string) (Scenario, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("read scenario: %w", err)
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+
+	var resp Scenario
+	if err := dec.Decode(&resp); err != nil {
+		return Scenario{}, fmt.Errorf("unmarshal scenario: %w", err)
+	} else if dec.More() {
+		return Scenario{}, fmt.Errorf("unmarshal scenario: trailing data")
+	} else if err := resp.verify(); err != nil {
+		return Scenario{}, err
+	}
+
+	return resp, nil
+}
+
+// verify returns an error if step labels are not unique.
+func (s Scenario) verify() error {
+	labels := make(map[string]bool)
+	for i, step := range s.Steps {
+		if step.Label == "" {
+			continue
+		} else if labels[step.Label] {
+			return fmt.Errorf("step %d: duplicate label %q", i, step.Label)
+		}
+		labels[step.Label] = true
+	}
+
+	return nil
+}
+
+// RunScenario executes the scenario, returning an error if any expectation is not met.
+func RunScenario(s Scenario) error {
+	if err := s.verify(); err != nil {
+		return err
+	}
+
+	var (
+		state  State
+		labels = make(map[string]SignedMutation)
+		heads  = make(map[Hash]string)
+		clock  = NewFakeClock(time.Unix(0, 0).UTC())
+	)
+	for i, step := range s.Steps {
+		clock.Advance(time.Second)
+
+		sm, err := step.mutation(clock, labels)
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+
+		if !step.Force {
+			verr := ValidateAddWithClock(clock, state, sm)
+			if err := checkError(verr, step.Error); err != nil {
+				return fmt.Errorf("step %d: validate: %w", i, err)
+			} else if verr != nil {
+				continue
+			}
+		}
+
+		state = append(state, sm)
+		if step.Label != "" {
+			labels[step.Label] = sm
+			heads[sm.Hash] = step.Label
+		}
+	}
+
+	reports, rerr := ResolveReport(state)
+	if rerr == nil {
+		// Surface the first error Resolve would return.
+		for _, report := range reports {
+			if report.Blocked != nil && !errors.Is(report.Blocked, ErrNotApproved) {
+				rerr = report.Blocked
+				break
+			}
+		}
+	}
+	if err := checkError(rerr, s.Resolve.Error); err != nil {
+		return fmt.Errorf("resolve: %w", err)
+	} else if rerr != nil {
+		return nil
+	}
+
+	for label, expect := range s.Resolve.Heads {
+		var found bool
+		for _, report := range reports {
+			if heads[report.Head] != label {
+				continue
+			}
+
+			if err := expect.check(report.Cluster); err != nil {
+				return fmt.Errorf("head %s: %w", label, err)
+			}
+			found = true
+		}
+
+		if !found {
+			return fmt.Errorf("head %s: not found", label)
+		}
+	}
+
+	return nil
+}
+
+// mutation returns the step's signed mutation.
+func (s ScenarioStep) mutation(clock Clock, labels map[string]SignedMutation) (SignedMutation, error) {
+	def, ok := typeDef[s.Type]
+	if !ok {
+		return SignedMutation{}, fmt.Errorf("unknown mutation type: %s", s.Type)
+	}
+
+	var data any
+	if def.DataType != nil {
+		ptr := reflect.New(reflect.TypeOf(def.DataType))
+		if len(s.Data) > 0 {
+			if err := json.Unmarshal(s.Data, ptr.Interface()); err != nil {
+				return SignedMutation{}, fmt.Errorf("unmarshal data: %w", err)
+			}
+		}
+		data = ptr.Elem().Interface()
+	}
+
+	var parents []SignedMutation
+	for _, label := range s.Parents {
+		parent, ok := labels[label]
+		if !ok {
+			return SignedMutation{}, fmt.Errorf("unknown parent label: %s", label)
+		}
+		parents = append(parents, parent)
+	}
+
+	return NewSignedMutation(clock, s.Source, s.Type, data, parents...), nil
+}
+
+func (c ScenarioCluster) check(cluster Cluster) error {
+	if c.ApprovedMutations != nil && *c.ApprovedMutations != cluster.ApprovedMutations {
+		return fmt.Errorf("approved mutations %d != %d", cluster.ApprovedMutations, *c.ApprovedMutations)
+	} else if c.Name != nil && *c.Name != cluster.Name {
+		return fmt.Errorf("name %s != %s", cluster.Name, *c.Name)
+	} else if c.NumValidators != nil && *c.NumValidators != cluster.NumValidators {
+		return fmt.Errorf("num validators %d != %d", cluster.NumValidators, *c.NumValidators)
+	} else if c.Validators != nil && *c.Validators != len(cluster.Validators) {
+		return fmt.Errorf("validators %d != %d", len(cluster.Validators), *c.Validators)
+	}
+
+	if c.Operators != nil {
+		var ops []PublicKey
+		for _, op := range cluster.Operators {
+			ops = append(ops, op.PublicKey)
+		}
+		if !reflect.DeepEqual(ops, c.Operators) {
+			return fmt.Errorf("operators %v != %v", ops, c.Operators)
+		}
+	}
+
+	if c.ENRs != nil {
+		var enrs int
+		for _, op := range cluster.Operators {
+			if op.ENR != "" {
+				enrs++
+			}
+		}
+		if enrs != *c.ENRs {
+			return fmt.Errorf("enrs %d != %d", enrs, *c.ENRs)
+		}
+	}
+
+	return nil
+}
+
+// checkError returns an error if err doesn't contain the expected substring, or if err is unexpected.
+func checkError(err error, expect string) error {
+	if expect == "" && err != nil {
+		return fmt.Errorf("unexpected error: %w", err)
+	} else if expect != "" && err == nil {
+		return fmt.Errorf("expected error: %s", expect)
+	} else if err != nil && !strings.Contains(err.Error(), expect) {
+		return fmt.Errorf("expected error %q, got: %w", expect, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package clusterstate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScenarios(t *testing.T) {
+	files, err := filepath.Glob("testdata/scenarios/*.json")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(files) == 0 {
+		t.Fatal("no scenarios")
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			s, err := LoadScenario(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := RunScenario(s); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestScenarioFailures(t *testing.T) {
+	create := ScenarioStep{
+		Label:  "create",
+		Source: "creator",
+		Type:   TypeCreateCluster,
+		Data:   json.RawMessage(`{"Name": "test", "Operators": ["alice", "bob"], "NumValidators": 1}`),
+	}
+	enr := ScenarioStep{
+		Label:   "enr",
+		Source:  "alice",
+		Type:    TypeOperatorENR,
+		Data:    json.RawMessage(`{"ENR": "enr://alice"}`),
+		Parents: []string{"create"},
+	}
+	two := 2
+
+	tests := []struct {
+		name     string
+		scenario Scenario
+		err      string
+	}{
+		{
+			name:     "unexpected validate error",
+			scenario: Scenario{Steps: []ScenarioStep{create, {Source: "alice", Type: TypeOperatorENR, Parents: []string{"create"}, Error: "duplicate"}}},
+			err:      "step 1: validate",
+		},
+		{
+			name:     "duplicate label",
+			scenario: Scenario{Steps: []ScenarioStep{create, enr, {Label: "enr", Source: "bob", Type: TypeOperatorENR, Parents: []string{"enr"}}}},
+			err:      `step 2: duplicate label "enr"`,
+		},
+		{
+			name:     "unknown parent",
+			scenario: Scenario{Steps: []ScenarioStep{create, {Source: "alice", Type: TypeOperatorENR, Parents: []string{"missing"}}}},
+			err:      "step 1",
+		},
+		{
+			name: "unexpected head",
+			scenario: Scenario{
+				Steps:   []ScenarioStep{create, enr},
+				Resolve: ScenarioResolve{Heads: map[string]ScenarioCluster{"enr": {ENRs: &two}}},
+			},
+			err: "head enr",
+		},
+		{
+			name: "missing head",
+			scenario: Scenario{
+				Steps:   []ScenarioStep{create, enr},
+				Resolve: ScenarioResolve{Heads: map[string]ScenarioCluster{"create": {}}},
+			},
+			err: "head create: not found",
+		},
+		{
+			name: "missing resolve error",
+			scenario: Scenario{
+				Steps:   []ScenarioStep{create, enr},
+				Resolve: ScenarioResolve{Error: "invalid"},
+			},
+			err: "resolve",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := RunScenario(test.scenario)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestLoadScenarioStrict(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		err  string
+	}{
+		{
+			name: "valid",
+			json: `{"name": "valid", "steps": [{"label": "create", "type": "charon/create_cluster/1.0.0"}]}`,
+		},
+		{
+			name: "unknown field",
+			json: `{"name": "typo", "steps": [], "reslove": {"error": "invalid"}}`,
+			err:  `unknown field "reslove"`,
+		},
+		{
+			name: "duplicate label",
+			json: `{"name": "dup", "steps": [{"label": "a"}, {"label": "a"}]}`,
+			err:  `step 1: duplicate label "a"`,
+		},
+		{
+			name: "trailing data",
+			json: `{"name": "trailing"} {}`,
+			err:  "trailing data",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "scenario.json")
+			if err := os.WriteFile(file, []byte(test.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := LoadScenario(file)
+			if test.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -1,50 +1,718 @@
 package clusterstate
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"math/rand"
+	"sort"
 	"time"
 )
 
 type SimulateConfig struct {
 	NumOperators int
+	// Seed seeds the randomised ordering and message delays.
+	Seed int64
+	// MaxDelay is the maximum message delivery delay in simulation ticks, defaults to 10.
+	MaxDelay int
+	// Faults maps operator indexes to byzantine behaviour, all other operators are honest.
+	Faults map[int]Fault
 }
 
-// Simulate simulates te building of a cluster state.
-func Simulate(conf SimulateConfig) error {
-	creator := PublicKey("creator")
-	operators := newOperators(conf.NumOperators)
+// Fault represents the byzantine behaviour of a simulated operator.
+type Fault int
 
-	_ = State{newSignedMutation(
-		creator,
+const (
+	FaultNone Fault = iota
+	// FaultOffline operators never send or relay mutations.
+	// The lifecycle cannot complete since they never submit their ENR.
+	FaultOffline
+	// FaultWithholdApprovals operators never approve proposals.
+	// The lifecycle cannot complete since some mutations require approvals from all operators.
+	FaultWithholdApprovals
+	// FaultEquivocate operators send conflicting mutations to different halves of the nodes.
+	FaultEquivocate
+	// FaultInvalidParents operators additionally send mutations with unknown or disallowed parents.
+	FaultInvalidParents
+	// FaultInvalidData operators propose mutations with invalid data.
+	FaultInvalidData
+	// FaultReplay operators additionally resend old mutations and replay them on new parents.
+	FaultReplay
+)
+
+// SimulateResult contains the safety and liveness metrics of a simulation.
+type SimulateResult struct {
+	// Safe is true if all honest nodes resolved the same finalised cluster.
+	Safe bool
+	// Live is true if the lifecycle completed and all proposals by honest operators were resolved.
+	Live bool
+	// Completed is true if the cluster lifecycle completed.
+	Completed bool
+	// Proposals is the number of mutations requiring approvals proposed by honest operators.
+	Proposals int
+	// Resolved is the number of honest proposals applied to the finalised cluster.
+	Resolved int
+	// Rejected is the number of mutations that honest nodes could not add to their state.
+	Rejected int
+	// Ticks is the duration of the simulation in simulation ticks.
+	Ticks int
+}
+
+// Simulate simulates te building of a cluster state by independent operators.
+// The operators submit ENRs, generate validators, add validators, change an operator
+// and submit a participation proof, broadcasting and relaying mutations with random delays.
+// Each step is proposed by a leader, the next operator takes over if the step times out.
+// Faulty operators deviate from this as configured.
+//
+// Without faults, it returns an error if the simulation is not safe and live
+// or any mutation is rejected, including the rejection reasons. With faults, the result should be inspected instead.
+//
+// The protocol cannot tolerate a single offline or approval withholding operator: every operator must
+// submit its ENR and generate validators requires ApprovalsAll, so such a fault blocks liveness
+// regardless of the number of operators, and only safety holds. Liveness under f < n/3 faulty
+// operators only holds for the equivocating, invalid and replaying faults.
+func Simulate(conf SimulateConfig) (SimulateResult, error) {
+	if conf.NumOperators < 1 {
+		return SimulateResult{}, fmt.Errorf("invalid number of operators")
+	} else if conf.MaxDelay <= 0 {
+		conf.MaxDelay = 10
+	}
+
+	for idx := range conf.Faults {
+		if idx < 0 || idx >= conf.NumOperators {
+			return SimulateResult{}, fmt.Errorf("invalid faulty operator index: %d", idx)
+		}
+	}
+
+	sim := newSimulation(conf)
+
+	creator := NewSignedMutation(
+		sim.clock,
+		PublicKey("creator"),
 		TypeCreateCluster,
-		newCreateCluster(operators),
-	)}
+		newCreateCluster(sim.operators),
+	)
+	sim.send(creator.Source, sim.keys, creator)
+
+	if err := sim.run(); err != nil {
+		return SimulateResult{}, err
+	}
+
+	res, err := sim.result()
+	if err != nil {
+		return SimulateResult{}, err
+	}
+
+	if len(conf.Faults) > 0 {
+		return res, nil
+	}
+
+	var errs []error
+	for _, key := range sim.keys {
+		node := sim.nodes[key]
+		for _, sm := range node.buffer {
+			errs = append(errs, fmt.Errorf("node %s: %w", key, node.bufferErr(sm)))
+		}
+	}
+
+	if len(errs) > 0 {
+		return SimulateResult{}, fmt.Errorf("rejected mutations: %w", errors.Join(errs...))
+	} else if !res.Safe {
+		return SimulateResult{}, fmt.Errorf("nodes resolved different clusters")
+	} else if !res.Live {
+		return SimulateResult{}, fmt.Errorf("lifecycle not completed")
+	}
+
+	return res, nil
+}
+
+// simStep is a step of the simulated cluster lifecycle.
+type simStep string
+
+const (
+	stepENR       simStep = "operator_enr"
+	stepGenerate  simStep = "generate_validators"
+	stepAdd       simStep = "add_validators"
+	stepChange    simStep = "change_operators"
+	stepProof     simStep = "participation_proof"
+	simMaxActions         = 1000000
+	// simTimeout is the number of message delays after which the next operator takes over a step's leadership.
+	simTimeout = 8
+)
+
+// simNode is a simulated charon node of an operator.
+type simNode struct {
+	key      PublicKey
+	fault    Fault
+	state    State
+	known    map[Hash]bool
+	buffer   []SignedMutation // Mutations that cannot be added yet.
+	errs     map[Hash]error   // Last error adding each buffered mutation.
+	tried    map[Hash]int     // State length when buffered mutations were last validated.
+	proposed map[string]bool
+	acked    map[Hash]bool
+	rejected map[Hash]bool  // Mutations that cannot be applied.
+	step     string         // Key of the current lifecycle step.
+	rounds   map[string]int // Leader rounds per step, incremented on timeouts.
+	timers   map[string]bool
+}
+
+// delivery is a mutation to be delivered to a node at a simulation tick,
+// or a timeout of the node's step if timeout is set.
+type delivery struct {
+	at      int
+	seq     int
+	to      PublicKey
+	sm      SignedMutation
+	timeout string
+}
+
+type simulation struct {
+	conf  SimulateConfig
+	rng   *rand.Rand
+	clock *FakeClock // Advances one second per tick.
+	now   int
+	seq   int
+	queue []delivery
+
+	nodes     map[PublicKey]*simNode
+	keys      []PublicKey // Node keys in deterministic order.
+	operators []PublicKey // Initial operators.
+	enrOrder  []PublicKey // Randomised ENR submission order.
+	leaders   map[simStep]PublicKey
+	removed   PublicKey // Operator replaced by ChangeOperators.
+	added     PublicKey // Operator added by ChangeOperators.
+}
+
+func newSimulation(conf SimulateConfig) *simulation {
+	rng := rand.New(rand.NewSource(conf.Seed))
+	operators := newOperators(conf.NumOperators + 1)
+
+	sim := &simulation{
+		conf:      conf,
+		rng:       rng,
+		clock:     NewFakeClock(time.Unix(0, 0).UTC()),
+		nodes:     make(map[PublicKey]*simNode),
+		keys:      operators,
+		operators: operators[:conf.NumOperators],
+		added:     operators[conf.NumOperators],
+		leaders:   make(map[simStep]PublicKey),
+	}
+
+	// The added operator runs a node from the start, it only participates once it is part of the cluster.
+	for i, key := range operators {
+		sim.nodes[key] = &simNode{
+			key:      key,
+			fault:    conf.Faults[i],
+			known:    make(map[Hash]bool),
+			proposed: make(map[string]bool),
+			acked:    make(map[Hash]bool),
+			rejected: make(map[Hash]bool),
+			errs:     make(map[Hash]error),
+			tried:    make(map[Hash]int),
+			rounds:   make(map[string]int),
+			timers:   make(map[string]bool),
+		}
+	}
+
+	sim.enrOrder = append([]PublicKey(nil), sim.operators...)
+	rng.Shuffle(len(sim.enrOrder), func(i, j int) {
+		sim.enrOrder[i], sim.enrOrder[j] = sim.enrOrder[j], sim.enrOrder[i]
+	})
+
+	sim.removed = sim.operators[rng.Intn(len(sim.operators))]
+	for _, step := range []simStep{stepGenerate, stepAdd, stepChange} {
+		sim.leaders[step] = sim.operators[rng.Intn(len(sim.operators))]
+	}
+
+	var final []PublicKey
+	for _, op := range sim.operators {
+		if op != sim.removed {
+			final = append(final, op)
+		}
+	}
+	final = append(final, sim.added)
+	sim.leaders[stepProof] = final[rng.Intn(len(final))]
+
+	return sim
+}
+
+// send sends the mutation from a node to the other nodes with random delays.
+// The mutation is delivered to its source immediately if included.
+func (s *simulation) send(from PublicKey, to []PublicKey, sm SignedMutation) {
+	for _, key := range to {
+		delay := 1 + s.rng.Intn(s.conf.MaxDelay)
+		if key == sm.Source {
+			delay = 0
+		} else if key == from {
+			continue
+		}
+
+		s.seq++
+		s.queue = append(s.queue, delivery{
+			at:  s.now + delay,
+			seq: s.seq,
+			to:  key,
+			sm:  sm,
+		})
+	}
+}
+
+// broadcast sends the node's mutation to all nodes. Equivocating nodes send
+// a conflicting copy of the mutation to half the nodes instead.
+func (s *simulation) broadcast(n *simNode, sm SignedMutation) {
+	if n.fault != FaultEquivocate {
+		s.send(n.key, s.keys, sm)
+		return
+	}
+
+	conflict := sm
+	conflict.Mutation.Timestamp = conflict.Mutation.Timestamp.Add(time.Nanosecond)
+	conflict.Hash = conflict.Mutation.Hash(conflict.Source)
+
+	half := len(s.keys) / 2
+	s.send(n.key, append([]PublicKey{n.key}, s.keys[:half]...), sm)
+	s.send(n.key, append([]PublicKey{n.key}, s.keys[half:]...), conflict)
+}
+
+// run delivers mutations until no more mutations are sent.
+func (s *simulation) run() error {
+	for i := 0; len(s.queue) > 0; i++ {
+		if i > simMaxActions {
+			return fmt.Errorf("simulation did not terminate")
+		}
+
+		sort.Slice(s.queue, func(i, j int) bool {
+			if s.queue[i].at != s.queue[j].at {
+				return s.queue[i].at < s.queue[j].at
+			}
+
+			return s.queue[i].seq < s.queue[j].seq
+		})
+
+		d := s.queue[0]
+		s.queue = s.queue[1:]
+		s.clock.Advance(time.Duration(d.at-s.now) * time.Second)
+		s.now = d.at
+
+		node := s.nodes[d.to]
+		if d.timeout != "" {
+			// Rotate the leader if the step has not progressed.
+			if node.fault == FaultOffline || node.step != d.timeout {
+				continue
+			}
+			node.rounds[d.timeout]++
+
+			if err := s.act(node); err != nil {
+				return fmt.Errorf("node %s: %w", node.key, err)
+			}
+
+			continue
+		}
+
+		added := node.receive(s.clock, d.sm)
+		if len(added) == 0 || node.fault == FaultOffline {
+			continue
+		}
+
+		// Relay mutations from other nodes.
+		for _, sm := range added {
+			if sm.Source != node.key {
+				s.send(node.key, s.keys, sm)
+			}
+		}
+
+		if err := s.act(node); err != nil {
+			return fmt.Errorf("node %s: %w", node.key, err)
+		}
+	}
+
+	return nil
+}
+
+// receive adds the mutation to the node's state once its parents are known and it is valid.
+// Mutations that cannot be added yet are buffered, since they may depend on approvals not received yet.
+// It returns the mutations that were added.
+func (n *simNode) receive(clock Clock, sm SignedMutation) []SignedMutation {
+	n.buffer = append(n.buffer, sm)
+
+	var resp []SignedMutation
+	for {
+		var (
+			added     bool
+			remaining []SignedMutation
+		)
+		for _, m := range n.buffer {
+			if n.known[m.Hash] {
+				continue
+			} else if n.rejected[m.Hash] || !n.parentsKnown(m) {
+				remaining = append(remaining, m)
+				continue
+			} else if tried, ok := n.tried[m.Hash]; ok && tried == len(n.state) {
+				// Validation only changes when the state does.
+				remaining = append(remaining, m)
+				continue
+			}
+
+			n.tried[m.Hash] = len(n.state)
+			if err := ValidateAddWithClock(clock, n.state, m); err != nil {
+				n.errs[m.Hash] = fmt.Errorf("validate %s from %s: %w", m.Mutation.Type, m.Source, err)
+				remaining = append(remaining, m)
+
+				continue
+			}
+
+			// Mutations without approvals are applied immediately, so reject those that cannot be applied
+			// instead of adding an invalid head to the state.
+			if m.Mutation.Type != TypeCreateCluster && isNoApprovals(m.Mutation.Type.Approvals()) {
+				next := append(n.state[:len(n.state):len(n.state)], m)
+				if _, _, err := Preview(next, m); err != nil && !errors.Is(err, ErrNotApproved) {
+					n.errs[m.Hash] = fmt.Errorf("preview %s from %s: %w", m.Mutation.Type, m.Source, err)
+					n.rejected[m.Hash] = true
+					remaining = append(remaining, m)
+
+					continue
+				}
+			}
+
+			n.state = append(n.state, m)
+			n.known[m.Hash] = true
+			resp = append(resp, m)
+			added = true
+		}
+
+		n.buffer = remaining
+
+		if !added {
+			return resp
+		}
+	}
+}
+
+// bufferErr returns the reason the buffered mutation could not be added to the node's state.
+func (n *simNode) bufferErr(sm SignedMutation) error {
+	if err, ok := n.errs[sm.Hash]; ok {
+		return err
+	} else if len(n.state) == 0 {
+		return fmt.Errorf("%s from %s before create cluster", sm.Mutation.Type, sm.Source)
+	}
+
+	return fmt.Errorf("%s from %s: %w", sm.Mutation.Type, sm.Source, ErrUnknownParent)
+}
+
+func (n *simNode) parentsKnown(sm SignedMutation) bool {
+	if len(n.state) == 0 {
+		return sm.Mutation.Type == TypeCreateCluster
+	}
+
+	for _, p := range sm.Mutation.ParentHashes {
+		if !n.known[p] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// act submits the mutations the node should send given its current state.
+func (s *simulation) act(n *simNode) error {
+	if err := s.approve(n); err != nil {
+		return err
+	}
+
+	reports, err := ResolveReport(n.state)
+	if err != nil {
+		return err
+	}
+
+	// Build on the last applied mutation of the best head.
+	head := bestHead(reports)
+	cluster := head.Cluster
+	parent := head.Applied[len(head.Applied)-1]
+
+	if n.fault != FaultNone {
+		s.misbehave(n, cluster, parent)
+	}
+
+	step, candidates := s.nextStep(cluster)
+	if step == "" {
+		return nil
+	}
+
+	var enrs int
+	for _, op := range cluster.Operators {
+		if op.ENR != "" {
+			enrs++
+		}
+	}
+
+	// Each step has a rotating leader, the next candidate takes over if the step doesn't progress before a timeout.
+	key := fmt.Sprintf("%s@%d/%d", step, cluster.ApprovedMutations, enrs)
+	n.step = key
+	round := n.rounds[key]
+	if timer := fmt.Sprintf("%s#%d", key, round); !n.timers[timer] && round < 2*len(candidates) {
+		n.timers[timer] = true
+		s.seq++
+		s.queue = append(s.queue, delivery{
+			at:      s.now + simTimeout*s.conf.MaxDelay,
+			seq:     s.seq,
+			to:      n.key,
+			timeout: key,
+		})
+	}
+
+	if candidates[round%len(candidates)] != n.key || n.proposed[key] || s.hasProposal(n, reports, parent) {
+		return nil
+	}
+	n.proposed[key] = true
+
+	var (
+		typ  MutationType
+		data any
+	)
+	switch step {
+	case stepENR:
+		typ, data = TypeOperatorENR, OperatorENR{ENR: fmt.Sprintf("enr://%s", n.key)}
+	case stepGenerate:
+		typ, data = TypeGenerateValidators, newGenerateValidators(cluster)
+	case stepAdd:
+		typ, data = TypeAddValidators, AddValidators{NumValidators: 1}
+	case stepChange:
+		var ops []PublicKey
+		for _, op := range cluster.Operators {
+			if op.PublicKey == s.removed {
+				ops = append(ops, s.added)
+			} else {
+				ops = append(ops, op.PublicKey)
+			}
+		}
+		typ, data = TypeChangeOperators, ChangeOperators{NewOperators: ops}
+	case stepProof:
+		typ, data = TypeParticipationProof, newParticipationProof(cluster)
+	}
+
+	if n.fault == FaultInvalidData {
+		data = newInvalidData(data)
+	}
+
+	s.broadcast(n, NewSignedMutation(s.clock, n.key, typ, data, parent))
 
-	panic("implement")
+	return nil
 }
 
-func newSignedMutation(souce PublicKey, typ MutationType, data any, parents ...SignedMutation) SignedMutation {
-	m := newMutation(typ, data, parents...)
+// nextStep returns the next lifecycle step of the cluster and the candidate leaders of the step in rotation order,
+// or an empty step if the lifecycle is complete.
+func (s *simulation) nextStep(cluster Cluster) (simStep, []PublicKey) {
+	rotate := func(step simStep) []PublicKey {
+		var ops []PublicKey
+		for _, op := range cluster.Operators {
+			ops = append(ops, op.PublicKey)
+		}
+
+		start := s.leaders[step]
+		for i, op := range ops {
+			if op == start {
+				return append(ops[i:], ops[:i]...)
+			}
+		}
 
-	return SignedMutation{
-		Mutation: m,
-		Hash:     m.Hash(),
-		Source:   souce,
+		return ops
+	}
+
+	var enrs []PublicKey
+	for _, key := range s.enrOrder {
+		for _, op := range cluster.Operators {
+			if op.PublicKey == key && op.ENR == "" {
+				enrs = append(enrs, key)
+			}
+		}
+	}
+
+	switch {
+	case cluster.ApprovedMutations == 1 && len(enrs) > 0:
+		// Operators submit their ENRs in turn, skipping those that time out.
+		return stepENR, enrs
+	case len(cluster.Validators) < cluster.NumValidators:
+		return stepGenerate, rotate(stepGenerate)
+	case cluster.NumValidators == newCreateCluster(nil).NumValidators:
+		return stepAdd, rotate(stepAdd)
+	case isOperator(s.removed, cluster):
+		return stepChange, rotate(stepChange)
+	case len(cluster.ParticipationProof) == 0:
+		return stepProof, rotate(stepProof)
+	default:
+		return "", nil
 	}
 }
 
-func newMutation(typ MutationType, data any, parents ...SignedMutation) Mutation {
-	var parentHashes []Hash
-	for _, parent := range parents {
-		parentHashes = append(parentHashes, parent.Hash)
+// hasProposal returns true if another valid proposal building on the parent is pending,
+// in which case a new leader waits for it instead of proposing a conflicting mutation.
+func (s *simulation) hasProposal(n *simNode, reports []HeadReport, parent SignedMutation) bool {
+	for _, report := range reports {
+		if report.Blocked == nil || !errors.Is(report.Blocked, ErrNotApproved) || n.rejected[report.Blocked.Hash] {
+			continue
+		}
+
+		proposal, _, err := n.state.Get(report.Blocked.Hash)
+		if err != nil {
+			continue
+		}
+
+		for _, p := range proposal.Mutation.ParentHashes {
+			if p == parent.Hash {
+				return true
+			}
+		}
 	}
 
-	return Mutation{
-		ParentHashes: parentHashes,
-		Type:         typ,
-		Data:         data,
-		Timestamp:    time.Now(),
+	return false
+}
+
+// approve approves all valid proposals missing the node's approval.
+func (s *simulation) approve(n *simNode) error {
+	if n.fault == FaultWithholdApprovals {
+		return nil
 	}
+
+	pending, err := PendingProposals(n.state)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range pending {
+		for _, proposal := range p.Proposals {
+			hash := proposal.Mutation.Hash
+			if n.acked[hash] || n.rejected[hash] || !contains(proposal.Missing, n.key) ||
+				!TypeOperatorAck.AllowsParent(proposal.Mutation.Mutation.Type) {
+				continue
+			}
+
+			// Only approve proposals that can be applied, retrying those that depend on unapproved mutations.
+			if _, _, err := Preview(n.state, proposal.Mutation); errors.Is(err, ErrNotApproved) {
+				continue
+			} else if err != nil {
+				n.rejected[hash] = true
+				continue
+			}
+
+			n.acked[hash] = true
+			s.broadcast(n, NewSignedMutation(s.clock, n.key, TypeOperatorAck, OperatorAck{}, proposal.Mutation))
+		}
+	}
+
+	return nil
+}
+
+// misbehave sends additional byzantine mutations, at most once per approved mutation.
+func (s *simulation) misbehave(n *simNode, cluster Cluster, parent SignedMutation) {
+	key := fmt.Sprintf("misbehave@%d", cluster.ApprovedMutations)
+	if n.proposed[key] {
+		return
+	}
+	n.proposed[key] = true
+
+	switch n.fault {
+	case FaultInvalidParents:
+		var unknown SignedMutation
+		s.rng.Read(unknown.Hash[:])
+		s.broadcast(n, NewSignedMutation(s.clock, n.key, TypeOperatorAck, OperatorAck{}, unknown))
+		s.broadcast(n, NewSignedMutation(s.clock, n.key, TypeAddValidators, AddValidators{NumValidators: 1}, n.state[0]))
+	case FaultReplay:
+		old := n.state[s.rng.Intn(len(n.state))]
+		s.send(n.key, s.keys, old)
+
+		for _, sm := range n.state {
+			if sm.Source == n.key && sm.Mutation.Type == TypeOperatorENR {
+				s.broadcast(n, NewSignedMutation(s.clock, n.key, sm.Mutation.Type, sm.Mutation.Data, parent))
+				break
+			}
+		}
+	}
+}
+
+// result returns the metrics of the completed simulation.
+func (s *simulation) result() (SimulateResult, error) {
+	resp := SimulateResult{
+		Safe:  true,
+		Ticks: s.now,
+	}
+
+	var (
+		honest   []*simNode
+		rejected = make(map[Hash]bool)
+	)
+	for _, key := range s.keys {
+		if node := s.nodes[key]; node.fault == FaultNone {
+			honest = append(honest, node)
+		}
+	}
+
+	var expect Cluster
+	for i, node := range honest {
+		for _, sm := range node.buffer {
+			rejected[sm.Hash] = true
+		}
+
+		reports, err := ResolveReport(node.state)
+		if err != nil {
+			return SimulateResult{}, fmt.Errorf("node %s: %w", node.key, err)
+		}
+
+		cluster := bestHead(reports).Cluster
+		if i == 0 {
+			expect = cluster
+		} else if len(Diff(expect, cluster)) > 0 {
+			resp.Safe = false
+		}
+	}
+
+	resp.Rejected = len(rejected)
+	resp.Completed = len(expect.ParticipationProof) > 0
+
+	if len(honest) > 0 {
+		for _, sm := range honest[0].state {
+			if s.nodes[sm.Source] == nil || s.nodes[sm.Source].fault != FaultNone ||
+				isNoApprovals(sm.Mutation.Type.Approvals()) {
+				continue
+			}
+
+			resp.Proposals++
+			if _, ok := expect.Hashes[sm.Hash]; ok {
+				resp.Resolved++
+			}
+		}
+	}
+
+	resp.Live = resp.Completed && resp.Resolved == resp.Proposals
+
+	return resp, nil
+}
+
+// bestHead returns the head with the most approved mutations, then the greatest height, then the lowest hash.
+func bestHead(reports []HeadReport) HeadReport {
+	reports = append([]HeadReport(nil), reports...)
+	sort.Slice(reports, func(i, j int) bool {
+		ci, cj := reports[i].Cluster, reports[j].Cluster
+		if ci.ApprovedMutations != cj.ApprovedMutations {
+			return ci.ApprovedMutations > cj.ApprovedMutations
+		} else if ci.Height != cj.Height {
+			return ci.Height > cj.Height
+		}
+
+		return bytes.Compare(reports[i].Head[:], reports[j].Head[:]) < 0
+	})
+
+	return reports[0]
+}
+
+func contains(keys []PublicKey, key PublicKey) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
 }
 
 func newCreateCluster(operators []PublicKey) CreateCluster {
@@ -56,6 +724,60 @@ func newCreateCluster(operators []PublicKey) CreateCluster {
 	}
 }
 
+// newGenerateValidators returns the validators missing from the cluster.
+func newGenerateValidators(cluster Cluster) GenerateValidators {
+	var resp GenerateValidators
+	for i := len(cluster.Validators); i < cluster.NumValidators; i++ {
+		v := Validator{PublicKey: PublicKey(fmt.Sprintf("validator-%d", i))}
+		for j := range cluster.Operators {
+			v.PublicShares = append(v.PublicShares, PublicKey(fmt.Sprintf("validator-%d/share-%d", i, j)))
+		}
+		resp.Validators = append(resp.Validators, v)
+	}
+
+	return resp
+}
+
+// newInvalidData returns an invalid version of the mutation data.
+func newInvalidData(data any) any {
+	switch d := data.(type) {
+	case GenerateValidators:
+		for i := range d.Validators {
+			d.Validators[i].PublicShares = d.Validators[i].PublicShares[1:]
+		}
+		return d
+	case AddValidators:
+		return AddValidators{NumValidators: -d.NumValidators}
+	case ChangeOperators:
+		return ChangeOperators{NewOperators: d.NewOperators[1:]}
+	case ParticipationProof:
+		d.StartEpoch, d.EndEpoch = d.EndEpoch, d.StartEpoch
+		return d
+	default:
+		return data
+	}
+}
+
+// newParticipationProof returns a proof of all operators performing attestation duties for all validators.
+func newParticipationProof(cluster Cluster) ParticipationProof {
+	const attester DutyType = 0
+
+	resp := ParticipationProof{
+		StartEpoch: 0,
+		EndEpoch:   100,
+		Validators: make(map[PublicKey]map[DutyType]map[PublicKey]int),
+	}
+	for _, v := range cluster.Validators {
+		counts := make(map[PublicKey]int)
+		for _, op := range cluster.Operators {
+			counts[op.PublicKey] = 100
+		}
+		resp.Validators[v.PublicKey] = map[DutyType]map[PublicKey]int{attester: counts}
+	}
+
+	return resp
+}
+
 func newOperators(n int) []PublicKey {
 	var resp []PublicKey
 	for i := 0; i < n; i++ {
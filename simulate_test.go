@@ -0,0 +1,130 @@
+package clusterstate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	for n := 1; n <= 7; n++ {
+		for seed := int64(0); seed < 3; seed++ {
+			if testing.Short() && n > 4 {
+				continue
+			}
+
+			t.Run(fmt.Sprintf("n=%d/seed=%d", n, seed), func(t *testing.T) {
+				res, err := Simulate(SimulateConfig{NumOperators: n, Seed: seed})
+				if err != nil {
+					t.Fatal(err)
+				} else if !res.Safe || !res.Live || res.Rejected > 0 || res.Resolved != res.Proposals {
+					t.Fatalf("unexpected result: %+v", res)
+				}
+			})
+		}
+	}
+}
+
+func TestSimulateDeterministic(t *testing.T) {
+	conf := SimulateConfig{NumOperators: 4, Seed: 42}
+
+	res1, err := Simulate(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res2, err := Simulate(conf)
+	if err != nil {
+		t.Fatal(err)
+	} else if res1 != res2 {
+		t.Fatalf("expected deterministic results: %+v != %+v", res1, res2)
+	}
+}
+
+func TestSimulateInvalidConfig(t *testing.T) {
+	if _, err := Simulate(SimulateConfig{}); err == nil {
+		t.Fatal("expected error for no operators")
+	}
+
+	if _, err := Simulate(SimulateConfig{NumOperators: 4, Faults: map[int]Fault{4: FaultOffline}}); err == nil {
+		t.Fatal("expected error for invalid faulty operator")
+	}
+}
+
+func TestSimulateFaults(t *testing.T) {
+	tests := []struct {
+		fault Fault
+		live  bool
+	}{
+		// A single offline or approval withholding operator blocks liveness, see Simulate.
+		{FaultOffline, false},
+		{FaultWithholdApprovals, false},
+		{FaultEquivocate, true},
+		{FaultInvalidParents, true},
+		{FaultInvalidData, true},
+		{FaultReplay, true},
+	}
+	for _, test := range tests {
+		for seed := int64(0); seed < 2; seed++ {
+			t.Run(fmt.Sprintf("fault=%d/seed=%d", test.fault, seed), func(t *testing.T) {
+				// A single faulty operator out of four, rotated by seed so it also leads some steps.
+				res, err := Simulate(SimulateConfig{
+					NumOperators: 4,
+					Seed:         seed,
+					Faults:       map[int]Fault{int(seed): test.fault},
+				})
+				if err != nil {
+					t.Fatal(err)
+				} else if !res.Safe {
+					t.Fatalf("expected safe: %+v", res)
+				} else if res.Live != test.live {
+					t.Fatalf("expected live=%v: %+v", test.live, res)
+				}
+			})
+		}
+	}
+}
+
+func TestSimulateFaultyLeaders(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large simulation")
+	}
+
+	// Operators 0 and 1 lead some steps with invalid proposals, the next leaders must take over.
+	res, err := Simulate(SimulateConfig{
+		NumOperators: 7,
+		Faults:       map[int]Fault{0: FaultInvalidData, 1: FaultInvalidData},
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if !res.Safe || !res.Live {
+		t.Fatalf("expected safe and live: %+v", res)
+	}
+}
+
+func TestSimNodeBufferErr(t *testing.T) {
+	sim := newSimulation(SimulateConfig{NumOperators: 2})
+	node := sim.nodes[sim.operators[0]]
+
+	create := NewSignedMutation(sim.clock, "creator", TypeCreateCluster, newCreateCluster(sim.operators))
+	if added := node.receive(sim.clock, create); len(added) != 1 {
+		t.Fatal("expected create cluster added")
+	}
+
+	var unknown SignedMutation
+	unknown.Hash[0] = 1
+	orphan := NewSignedMutation(sim.clock, sim.operators[0], TypeOperatorAck, OperatorAck{}, unknown)
+	invalid := NewSignedMutation(sim.clock, "stranger", TypeOperatorENR, OperatorENR{ENR: "enr://x"}, create)
+	for _, sm := range []SignedMutation{orphan, invalid} {
+		if added := node.receive(sim.clock, sm); len(added) != 0 {
+			t.Fatalf("expected %s buffered", sm.Mutation.Type)
+		}
+	}
+
+	if err := node.bufferErr(orphan); !errors.Is(err, ErrUnknownParent) {
+		t.Fatalf("expected unknown parent, got %v", err)
+	} else if err := node.bufferErr(invalid); err == nil || !strings.Contains(err.Error(), "operator not found") {
+		t.Fatalf("expected operator not found, got %v", err)
+	}
+}
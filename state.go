@@ -40,27 +40,45 @@ func (s State) Children(h Hash) ([]SignedMutation, error) {
 
 // ApprovedBy returns the operators that have approved (built-on) the given mutation.
 func (s State) ApprovedBy(hash Hash) (map[PublicKey]bool, error) {
-	children, err := s.Children(hash)
-	if err != nil {
-		return nil, err
+	return s.children().approvedBy(hash), nil
+}
+
+// childIndex indexes the children of each mutation by parent hash.
+type childIndex map[Hash][]SignedMutation
+
+// children returns the index of the children of all mutations in the state.
+func (s State) children() childIndex {
+	resp := make(childIndex)
+	for _, m := range s {
+		for _, p := range m.Mutation.ParentHashes {
+			resp[p] = append(resp[p], m)
+		}
 	}
 
+	return resp
+}
+
+// approvedBy returns the sources of all descendants of the given mutation.
+func (c childIndex) approvedBy(hash Hash) map[PublicKey]bool {
 	resp := make(map[PublicKey]bool)
-	for len(children) > 0 {
-		child := children[0]
+	visited := make(map[Hash]bool)
+	buffer := c[hash]
+	for len(buffer) > 0 {
+		child := buffer[0]
+		buffer = buffer[1:]
+		if visited[child.Hash] {
+			continue
+		}
+		visited[child.Hash] = true
 		resp[child.Source] = true
 
-		more, err := s.Children(child.Hash)
-		if err != nil {
-			return nil, err
-		}
-		children = append(children, more...)
+		buffer = append(buffer, c[child.Hash]...)
 	}
 
-	return resp, nil
+	return resp
 }
 
-// Leaves returns the leaves of the DAG.
+// Leaves returns the leaves of the DAG sorted by hash.
 func (s State) Leaves() []Hash {
 	hasChildren := map[Hash]bool{}
 	for _, m := range s {
@@ -81,15 +99,23 @@ func (s State) Leaves() []Hash {
 		resp = append(resp, hash)
 	}
 
+	sort.Slice(resp, func(i, j int) bool {
+		return bytes.Compare(resp[i][:], resp[j][:]) < 0
+	})
+
 	return resp
 }
 
 // Heights returns the heights of all mutations in the state,
 // the number of mutations each is built on.
 func (s State) Heights() (map[Hash]int, error) {
-	buffer := []Hash{s[0].Hash}
-	heights := map[Hash]int{
-		s[0].Hash: 1,
+	var buffer []Hash
+	heights := make(map[Hash]int)
+	for _, sm := range s {
+		if len(sm.Mutation.ParentHashes) == 0 {
+			buffer = append(buffer, sm.Hash)
+			heights[sm.Hash] = 1
+		}
 	}
 
 	for len(buffer) > 0 {
@@ -106,10 +132,13 @@ func (s State) Heights() (map[Hash]int, error) {
 
 		childHeight := heights[h] + 1
 		for _, child := range children {
-			if heights[child.Hash] < childHeight {
-				heights[child.Hash] = childHeight
+			if heights[child.Hash] >= childHeight {
+				continue
+			} else if childHeight > len(s) {
+				return nil, fmt.Errorf("cycle detected")
 			}
 
+			heights[child.Hash] = childHeight
 			buffer = append(buffer, child.Hash)
 		}
 	}
@@ -19,16 +19,27 @@ type Hash [32]byte
 // MutationType represents the type of a mutation.
 type MutationType string
 
-func (t MutationType) Approvals() Approvals {
-	return typeDef[t].Approvals
+// Approvals returns the approval policy of the version the mutation type is upgraded to before being applied.
+func (t MutationType) Approvals() ApprovalPolicy {
+	return typeDef[t.upgradeTarget()].Approvals
 }
 
+// ParentTypes returns the types that the version the mutation type is upgraded to may build on.
 func (t MutationType) ParentTypes() map[MutationType]bool {
 	resp := make(map[MutationType]bool)
-	for _, p := range typeDef[t].ParentTypes {
+	for _, p := range typeDef[t.upgradeTarget()].ParentTypes {
 		resp[p] = true
 	}
 
+	// Custom types may declare any version of themselves as allowed parents of existing types.
+	for p, def := range typeDef {
+		for _, c := range def.ChildTypes {
+			if c.Name() == t.Name() {
+				resp[p] = true
+			}
+		}
+	}
+
 	return resp
 }
 
@@ -59,8 +70,13 @@ type Mutation struct {
 	Timestamp    time.Time
 }
 
-func (m Mutation) Hash() Hash {
-	b, err := json.Marshal(m)
+// Hash returns the hash of the mutation created by the source.
+// The source is included since sources may create identical mutations at the same time, e.g. approvals.
+func (m Mutation) Hash(source PublicKey) Hash {
+	b, err := json.Marshal(struct {
+		Mutation Mutation
+		Source   PublicKey
+	}{m, source})
 	if err != nil {
 		panic(err)
 	}
@@ -68,6 +84,27 @@ func (m Mutation) Hash() Hash {
 	return Hash(sha256.Sum256(b))
 }
 
+// NewSignedMutation returns a mutation by the source building on the parents, timestamped by the clock.
+func NewSignedMutation(clock Clock, source PublicKey, typ MutationType, data any, parents ...SignedMutation) SignedMutation {
+	var parentHashes []Hash
+	for _, parent := range parents {
+		parentHashes = append(parentHashes, parent.Hash)
+	}
+
+	m := Mutation{
+		ParentHashes: parentHashes,
+		Type:         typ,
+		Data:         data,
+		Timestamp:    clock.Now(),
+	}
+
+	return SignedMutation{
+		Mutation: m,
+		Hash:     m.Hash(source),
+		Source:   source,
+	}
+}
+
 // CreateCluster represents the TypeCreateCluster mutation data.
 type CreateCluster struct {
 	Name              string
@@ -119,36 +156,27 @@ type ParticipationProof struct {
 
 // AppendToCluster appends the mutation to the cluster, returning the new cluster state.
 func AppendToCluster(sm SignedMutation, cluster Cluster) (Cluster, error) {
-	m, ok := typeDef[sm.Mutation.Type]
-	if !ok {
-		return Cluster{}, fmt.Errorf("unknown mutation type: %s", sm.Mutation.Type)
+	upgraded, err := upgrade(sm)
+	if err != nil {
+		return Cluster{}, err
 	}
 
-	resp, err := m.AppendFunc(sm, cluster.Clone())
+	resp, err := typeDef[upgraded.Mutation.Type].AppendFunc(upgraded, cluster.Clone())
 	if err != nil {
 		return Cluster{}, err
 	}
 
+	if resp.Hashes == nil {
+		resp.Hashes = make(map[Hash]SignedMutation)
+	}
+
 	resp.Height++
 	resp.Hashes[sm.Hash] = sm
 
 	return resp, nil
 }
 
-type Approvals int
-
-const (
-	ApprovalsNone Approvals = iota
-	ApprovalsQuorum
-	ApprovalsAll
-)
-
-var typeDef = map[MutationType]struct {
-	Approvals   Approvals
-	DataType    any
-	ParentTypes []MutationType
-	AppendFunc  func(SignedMutation, Cluster) (Cluster, error)
-}{
+var typeDef = map[MutationType]TypeDef{
 	TypeCreateCluster: {
 		Approvals: ApprovalsNone,
 		DataType:  CreateCluster{},
@@ -163,6 +191,7 @@ var typeDef = map[MutationType]struct {
 			}
 
 			if c.Name != "" ||
+				c.Creator != "" ||
 				c.Height != 0 ||
 				c.ApprovedMutations != 0 ||
 				len(c.Operators) != 0 ||
@@ -183,6 +212,7 @@ var typeDef = map[MutationType]struct {
 			return Cluster{
 				ApprovedMutations: 1,
 				Name:              cc.Name,
+				Creator:           m.Source,
 				Operators:         ops,
 				NumValidators:     cc.NumValidators,
 				WithdrawalAddress: cc.WithdrawalAddress,
@@ -203,7 +233,12 @@ var typeDef = map[MutationType]struct {
 					return Cluster{}, fmt.Errorf("operator already has enr")
 				}
 
-				c.Operators[i].ENR = m.Mutation.Data.(OperatorENR).ENR
+				enr, ok := m.Mutation.Data.(OperatorENR)
+				if !ok {
+					return Cluster{}, fmt.Errorf("invalid data type")
+				}
+
+				c.Operators[i].ENR = enr.ENR
 
 				return c, nil
 			}
@@ -221,8 +256,10 @@ var typeDef = map[MutationType]struct {
 				return Cluster{}, fmt.Errorf("cluster already has all validators")
 			}
 
-			gv := m.Mutation.Data.(GenerateValidators)
-			if len(gv.Validators) > missing {
+			gv, ok := m.Mutation.Data.(GenerateValidators)
+			if !ok {
+				return Cluster{}, fmt.Errorf("invalid data type")
+			} else if len(gv.Validators) > missing {
 				return Cluster{}, fmt.Errorf("too many validators")
 			}
 
@@ -244,7 +281,14 @@ var typeDef = map[MutationType]struct {
 		DataType:    AddValidators{},
 		ParentTypes: []MutationType{TypeOperatorAck, TypeOperatorENR},
 		AppendFunc: func(m SignedMutation, c Cluster) (Cluster, error) {
-			c.NumValidators += m.Mutation.Data.(AddValidators).NumValidators
+			av, ok := m.Mutation.Data.(AddValidators)
+			if !ok {
+				return Cluster{}, fmt.Errorf("invalid data type")
+			} else if av.NumValidators <= 0 {
+				return Cluster{}, fmt.Errorf("invalid add validators")
+			}
+
+			c.NumValidators += av.NumValidators
 
 			c.ApprovedMutations++
 
@@ -254,7 +298,7 @@ var typeDef = map[MutationType]struct {
 	TypeOperatorAck: {
 		Approvals:   ApprovalsNone,
 		DataType:    OperatorAck{},
-		ParentTypes: []MutationType{TypeAddValidators, TypeGenerateValidators, TypeReshareValidators},
+		ParentTypes: []MutationType{TypeAddValidators, TypeGenerateValidators, TypeReshareValidators, TypeChangeOperators},
 		AppendFunc: func(m SignedMutation, c Cluster) (Cluster, error) {
 
 			return c, nil
@@ -265,8 +309,10 @@ var typeDef = map[MutationType]struct {
 		DataType:    ChangeOperators{},
 		ParentTypes: []MutationType{TypeOperatorAck, TypeOperatorENR},
 		AppendFunc: func(m SignedMutation, c Cluster) (Cluster, error) {
-			co := m.Mutation.Data.(ChangeOperators)
-			if len(co.NewOperators) != len(c.Operators) {
+			co, ok := m.Mutation.Data.(ChangeOperators)
+			if !ok {
+				return Cluster{}, fmt.Errorf("invalid data type")
+			} else if len(co.NewOperators) != len(c.Operators) {
 				return Cluster{}, fmt.Errorf("invalid change operators")
 			}
 
@@ -294,8 +340,10 @@ var typeDef = map[MutationType]struct {
 					return Cluster{}, fmt.Errorf("operator has no enr")
 				}
 			}
-			rv := m.Mutation.Data.(ReshareValidators)
-			if len(rv.NewValidators) != len(c.Validators) {
+			rv, ok := m.Mutation.Data.(ReshareValidators)
+			if !ok {
+				return Cluster{}, fmt.Errorf("invalid data type")
+			} else if len(rv.NewValidators) != len(c.Validators) {
 				return Cluster{}, fmt.Errorf("invalid reshare")
 			}
 			for i := 0; i < len(c.Validators); i++ {
@@ -318,7 +366,12 @@ var typeDef = map[MutationType]struct {
 		DataType:    ParticipationProof{},
 		ParentTypes: []MutationType{TypeParticipationProof, TypeOperatorAck, TypeOperatorENR},
 		AppendFunc: func(m SignedMutation, c Cluster) (Cluster, error) {
-			pp := m.Mutation.Data.(ParticipationProof)
+			pp, ok := m.Mutation.Data.(ParticipationProof)
+			if !ok {
+				return Cluster{}, fmt.Errorf("invalid data type")
+			} else if pp.EndEpoch < pp.StartEpoch {
+				return Cluster{}, fmt.Errorf("invalid participation proof epochs")
+			}
 			for _, prev := range c.ParticipationProof {
 				if pp.StartEpoch >= prev.StartEpoch && pp.StartEpoch <= prev.EndEpoch {
 					return Cluster{}, fmt.Errorf("overlapping participation proof")
@@ -0,0 +1,119 @@
+package v5
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// fuzzMutation is the JSON encoding of a SignedMutation with data decoded according to its type.
+type fuzzMutation struct {
+	Hash                 Hash
+	Source               PublicKey
+	ParentMutationHashes []Hash
+	ParentOperationHash  Hash
+	Type                 MutationType
+	Data                 json.RawMessage
+}
+
+func FuzzMaterialise(f *testing.F) {
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`[{"Type":"charon/propose_cluster/1.0.0","Data":{"Operators":["a"]}}]`))
+	f.Add([]byte(`[{"Type":"charon/dkg/1.0.0","Data":[{"PublicKey":"v"}]}]`))
+
+	dag := newTestDAG(newTestOperators(3), 2)
+	for i := 1; i <= len(dag); i++ {
+		f.Add(fuzzEncode(f, dag[:i]))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dag, ok := fuzzDAG(data)
+		if !ok {
+			return
+		}
+
+		_, _ = Materialise(dag)
+		_ = ListOperations(dag)
+		_, _ = SortDAG(dag)
+	})
+}
+
+// fuzzEncode returns the JSON encoding of the DAG as a list of fuzzMutations.
+func fuzzEncode(tb testing.TB, dag RawDAG) []byte {
+	var mutations []fuzzMutation
+	for _, sm := range dag {
+		data, err := json.Marshal(sm.Mutation.Data)
+		if err != nil {
+			tb.Fatal(err)
+		}
+
+		mutations = append(mutations, fuzzMutation{
+			Hash:                 sm.Hash,
+			Source:               sm.Source,
+			ParentMutationHashes: sm.Mutation.ParentMutationHashes,
+			ParentOperationHash:  sm.Mutation.ParentOperationHash,
+			Type:                 sm.Mutation.Type,
+			Data:                 data,
+		})
+	}
+
+	b, err := json.Marshal(mutations)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return b
+}
+
+// fuzzDAG decodes the JSON encoded list of fuzzMutations.
+func fuzzDAG(data []byte) (RawDAG, bool) {
+	var mutations []fuzzMutation
+	if err := json.Unmarshal(data, &mutations); err != nil {
+		return nil, false
+	}
+
+	var dag RawDAG
+	for _, m := range mutations {
+		var data any
+		if def, ok := typeDef[m.Type]; ok && def.DataType != nil {
+			ptr := reflect.New(reflect.TypeOf(def.DataType))
+			if err := json.Unmarshal(m.Data, ptr.Interface()); err != nil {
+				return nil, false
+			}
+			data = ptr.Elem().Interface()
+		} else if len(m.Data) > 0 {
+			if err := json.Unmarshal(m.Data, &data); err != nil {
+				return nil, false
+			}
+		}
+
+		dag = append(dag, SignedMutation{
+			Mutation: Mutation{
+				ParentMutationHashes: m.ParentMutationHashes,
+				ParentOperationHash:  m.ParentOperationHash,
+				Type:                 m.Type,
+				Data:                 data,
+			},
+			Hash:   m.Hash,
+			Source: m.Source,
+		})
+	}
+
+	return dag, true
+}
+
+func TestFuzzEncodeRoundTrip(t *testing.T) {
+	dag := newTestDAG(newTestOperators(3), 2)
+
+	decoded, ok := fuzzDAG(fuzzEncode(t, dag))
+	if !ok {
+		t.Fatal("failed to decode")
+	}
+
+	// The seed corpus must materialise, otherwise the fuzzer starts from rejected inputs only.
+	if _, err := Materialise(decoded); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(dag, decoded) {
+		t.Fatal("decoded DAG does not match")
+	}
+}
@@ -0,0 +1,106 @@
+package v5
+
+import "fmt"
+
+// dagBuilder builds v5 DAGs for tests.
+type dagBuilder struct {
+	dag RawDAG
+	// operation is the begin mutation of the latest operation.
+	operation SignedMutation
+}
+
+// begin adds a mutation beginning a new operation that builds on the latest operation.
+func (b *dagBuilder) begin(source PublicKey, typ MutationType, data any) SignedMutation {
+	m := Mutation{
+		ParentOperationHash: b.operation.Hash,
+		Type:                typ,
+		Data:                data,
+	}
+	if len(b.dag) > 0 {
+		m.ParentMutationHashes = []Hash{b.dag[len(b.dag)-1].Hash}
+	}
+
+	b.operation = b.append(source, m)
+
+	return b.operation
+}
+
+// add adds a mutation to the latest operation.
+func (b *dagBuilder) add(source PublicKey, typ MutationType, data any) SignedMutation {
+	return b.append(source, Mutation{
+		ParentMutationHashes: []Hash{b.operation.Hash},
+		ParentOperationHash:  b.operation.Mutation.ParentOperationHash,
+		Type:                 typ,
+		Data:                 data,
+	})
+}
+
+func (b *dagBuilder) append(source PublicKey, m Mutation) SignedMutation {
+	sm := SignedMutation{
+		Mutation: m,
+		Hash:     m.Hash(source),
+		Source:   source,
+	}
+	b.dag = append(b.dag, sm)
+
+	return sm
+}
+
+// newTestDAG returns a DAG proposing, accepting and generating validators of a cluster.
+func newTestDAG(operators []PublicKey, numValidators int) RawDAG {
+	b := new(dagBuilder)
+	b.begin(operators[0], TypeProposeCluster, ProposeCluster{
+		Name:       "test",
+		Operators:  operators,
+		Validators: make([]Validator, numValidators),
+	})
+
+	b.begin(operators[0], TypeAcceptClusterBegin, nil)
+	for _, op := range operators {
+		b.add(op, TypeOperatorENR, OperatorENR{ENR: fmt.Sprintf("enr://%s", op)})
+	}
+	b.add(operators[0], TypeAcceptClusterEnd, nil)
+
+	b.begin(operators[0], TypeDKG, newTestValidators(operators, numValidators))
+
+	return b.dag
+}
+
+// newTestValidators returns deterministic validators with a share per operator.
+func newTestValidators(operators []PublicKey, n int) Validators {
+	var resp Validators
+	for i := 0; i < n; i++ {
+		v := Validator{PublicKey: PublicKey(fmt.Sprintf("validator-%d", i))}
+		for j := range operators {
+			v.PublicShares = append(v.PublicShares, PublicKey(fmt.Sprintf("validator-%d/share-%d", i, j)))
+		}
+		resp = append(resp, v)
+	}
+
+	return resp
+}
+
+func newTestOperators(n int) []PublicKey {
+	var resp []PublicKey
+	for i := 0; i < n; i++ {
+		resp = append(resp, PublicKey(fmt.Sprintf("operator-%d", i)))
+	}
+
+	return resp
+}
+
+// addTestRegistrations adds a register validators operation with a registration per validator,
+// approved by the approving operators.
+func (b *dagBuilder) addTestRegistrations(vals Validators, approving []PublicKey) {
+	b.begin(approving[0], TypeRegisterValidatorsBegin, nil)
+	for _, val := range vals {
+		b.add(approving[0], TypeValidatorRegistration, ValidatorRegistration{
+			Validator:    val.PublicKey,
+			FeeRecipient: "0x" + string(val.PublicKey),
+		})
+	}
+	for _, op := range approving {
+		b.add(op, TypeOperatorApproval, nil)
+	}
+	b.add(approving[0], TypeRegisterValidatorsEnd, nil)
+}
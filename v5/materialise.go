@@ -2,49 +2,156 @@ package v5
 
 import "fmt"
 
+// Materialise returns the cluster state resulting from the ordered DAG, use SortDAG to order DAGs received out of order.
 func Materialise(dag RawDAG) (ClusterState, error) {
-	var (
-		state           ClusterState
-		activeOperation                                    = OperationUnknown
-		spread                                             = SpreadUnknown
-		spreadValidator func(SignedMutation) (bool, error) = nil
-	)
+	m := newMaterialiser()
 	for i, mutation := range dag {
-		typ := mutation.Mutation.Type
-		beginOperation, begins := typ.BeginsOperation()
-		endsOperation := typ.EndsOperation()
-
-		if activeOperation == OperationUnknown && !begins {
-			return ClusterState{}, fmt.Errorf("mutation %d does not begin a new operation", i)
-		} else if activeOperation != OperationUnknown && begins {
-			return ClusterState{}, fmt.Errorf("mutation %d begins a new operation, but the previous operation has not ended", i)
+		if err := m.apply(mutation); err != nil {
+			return ClusterState{}, fmt.Errorf("mutation %d: %w", i, err)
 		}
+	}
 
-		if begins {
-			activeOperation = beginOperation
-		}
+	if m.active != OperationUnknown {
+		return ClusterState{}, fmt.Errorf("operation %s has not ended", m.active)
+	}
+
+	return m.state, nil
+}
+
+// materialiser applies mutations one at a time, tracking the active operation.
+type materialiser struct {
+	state           ClusterState
+	active          OperationType
+	previousType    OperationType
+	previous        Hash           // Begin mutation hash of the previous operation.
+	previousEnd     Hash           // End mutation hash of the previous operation.
+	begin           SignedMutation // Begin mutation of the active operation.
+	seen            map[Hash]bool  // Hashes of applied mutations.
+	step            int            // Index of the active operation's sequence.
+	count           int            // Number of mutations in the active step.
+	spreadValidator func(SignedMutation) (bool, error)
+	spreadDone      bool
+}
+
+func newMaterialiser() *materialiser {
+	return &materialiser{
+		active:       OperationUnknown,
+		previousType: OperationUnknown,
+		seen:         make(map[Hash]bool),
+	}
+}
+
+// apply validates and applies the next mutation.
+func (m *materialiser) apply(mutation SignedMutation) error {
+	typ := mutation.Mutation.Type
+	if _, ok := typeDef[typ]; !ok {
+		return fmt.Errorf("unknown type: %s", typ)
+	} else if mutation.Hash != mutation.Mutation.Hash(mutation.Source) {
+		return fmt.Errorf("invalid mutation hash")
+	}
 
-		if spread == SpreadUnknown {
-			spread = typ.Spread()
-			spreadValidator = spread.NewValidatorFunc(state)
+	beginOperation, begins := typ.BeginsOperation()
+
+	if m.active == OperationUnknown && !begins {
+		return fmt.Errorf("does not begin a new operation")
+	} else if m.active != OperationUnknown && begins {
+		return fmt.Errorf("begins a new operation, but the previous operation has not ended")
+	}
+
+	if begins {
+		if !beginOperation.AllowsParent(m.previousType) {
+			return fmt.Errorf("begins operation %s which may not follow %s", beginOperation, m.previousType)
 		}
 
-		spreadDone, err := spreadValidator(mutation)
-		if err != nil {
-			return ClusterState{}, fmt.Errorf("invalid mutation spread %d: %w", i, err)
+		m.active = beginOperation
+		m.begin = mutation
+		m.step = 0
+		m.count = 0
+		m.spreadValidator = nil
+	}
+
+	if mutation.Mutation.ParentOperationHash != m.previous {
+		return fmt.Errorf("invalid parent operation hash")
+	}
+
+	parents := mutation.Mutation.ParentMutationHashes
+	for _, p := range parents {
+		if !m.seen[p] {
+			return fmt.Errorf("unknown parent mutation")
 		}
+	}
+
+	if begins && m.previousEnd != (Hash{}) && !containsHash(parents, m.previousEnd) {
+		return fmt.Errorf("does not reference the previous operation's end mutation")
+	} else if !begins && !containsHash(parents, m.begin.Hash) {
+		return fmt.Errorf("does not reference the operation's begin mutation")
+	}
 
-		state, err := typ.Transform(state, mutation)
-		if err != nil {
-			return ClusterState{}, fmt.Errorf("mutation transform %d: %w", i, err)
+	// Advance to the next type in the operation's sequence once the current spread is done.
+	sequence := operationDef[m.active].Sequence
+	if m.spreadValidator != nil && m.spreadDone && sequence[m.step] == typ && spreadDef[typ.Spread()].Late {
+		// Late mutations of a done spread are validated but not applied, e.g. approvals after a quorum.
+		if _, err := m.spreadValidator(mutation); err != nil {
+			return fmt.Errorf("invalid spread: %w", err)
 		}
+		m.count++
+		m.seen[mutation.Hash] = true
+
+		return nil
+	} else if m.spreadValidator != nil && m.spreadDone {
+		m.step++
+		m.count = 0
+		m.spreadValidator = nil
+	}
+	if m.step >= len(sequence) || sequence[m.step] != typ {
+		return fmt.Errorf("unexpected type %s in operation %s", typ, m.active)
+	}
+
+	if m.spreadValidator == nil {
+		m.spreadValidator = typ.Spread().NewValidatorFunc(m.state)
+	}
+
+	var err error
+	m.spreadDone, err = m.spreadValidator(mutation)
+	if err != nil {
+		return fmt.Errorf("invalid spread: %w", err)
+	}
+	m.count++
+
+	if err := typ.Validate(m.state, mutation); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+
+	state, err := typ.Transform(m.state, mutation)
+	if err != nil {
+		return fmt.Errorf("transform: %w", err)
+	}
+	m.state = state
 
-		if spreadDone {
-			spread = SpreadUnknown
-			spreadValidator = nil
+	if typ.EndsOperation() {
+		if !m.spreadDone || m.step != len(sequence)-1 {
+			return fmt.Errorf("ends operation %s before it is complete", m.active)
 		}
 
+		m.previous = m.begin.Hash
+		m.previousEnd = mutation.Hash
+		m.previousType = m.active
+		m.active = OperationUnknown
+	}
+
+	m.seen[mutation.Hash] = true
+
+	return nil
+}
+
+func containsHash(hashes []Hash, hash Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
 	}
+
+	return false
 }
 
 type OperationType string
@@ -54,8 +161,49 @@ const (
 	OperationProposeCluster     OperationType = "charon/operation/propose_cluster/1.0.0"
 	OperationAcceptCluster      OperationType = "charon/operation/accept_cluster/1.0.0"
 	OperationGenerateValidators OperationType = "charon/operation/generate_validators/1.0.0"
+	OperationRegisterValidators OperationType = "charon/operation/register_validators/1.0.0"
 )
 
-var operationDef = struct {
-	structure
-}{}
+// AllowsParent returns true if the operation may follow the parent operation,
+// with OperationUnknown denoting the start of the DAG.
+func (o OperationType) AllowsParent(parent OperationType) bool {
+	def, ok := operationDef[o]
+	if !ok {
+		return false
+	}
+
+	for _, p := range def.ParentOperations {
+		if p == parent {
+			return true
+		}
+	}
+
+	return false
+}
+
+var operationDef = map[OperationType]struct {
+	// Sequence is the allowed sequence of mutation types, each repeated according to its spread.
+	Sequence []MutationType
+	// ParentOperations are the operations this operation may follow.
+	ParentOperations []OperationType
+}{
+	OperationProposeCluster: {
+		Sequence:         []MutationType{TypeProposeCluster},
+		ParentOperations: []OperationType{OperationUnknown},
+	},
+	OperationAcceptCluster: {
+		Sequence:         []MutationType{TypeAcceptClusterBegin, TypeOperatorENR, TypeAcceptClusterEnd},
+		ParentOperations: []OperationType{OperationProposeCluster},
+	},
+	OperationGenerateValidators: {
+		Sequence:         []MutationType{TypeDKG},
+		ParentOperations: []OperationType{OperationAcceptCluster},
+	},
+	OperationRegisterValidators: {
+		Sequence: []MutationType{
+			TypeRegisterValidatorsBegin, TypeValidatorRegistration,
+			TypeOperatorApproval, TypeRegisterValidatorsEnd,
+		},
+		ParentOperations: []OperationType{OperationGenerateValidators, OperationRegisterValidators},
+	},
+}
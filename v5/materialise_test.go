@@ -0,0 +1,115 @@
+package v5
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMaterialise(t *testing.T) {
+	ops := newTestOperators(3)
+	dag := newTestDAG(ops, 2)
+
+	state, err := Materialise(dag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.Name != "test" {
+		t.Fatalf("unexpected name: %s", state.Name)
+	}
+	for i, op := range state.Operators {
+		if op.PublicKey != ops[i] || op.ENR != "enr://"+string(ops[i]) {
+			t.Fatalf("unexpected operator %d: %+v", i, op)
+		}
+	}
+	if vals := newTestValidators(ops, 2); !reflect.DeepEqual(state.Validators, []Validator(vals)) {
+		t.Fatalf("unexpected validators: %+v", state.Validators)
+	}
+
+	// Each completed operation can be materialised on its own.
+	for _, n := range []int{1, 1 + len(ops) + 2} {
+		if _, err := Materialise(dag[:n]); err != nil {
+			t.Fatalf("prefix %d: %v", n, err)
+		}
+	}
+}
+
+func TestMaterialiseInvalid(t *testing.T) {
+	ops := newTestOperators(3)
+
+	tests := []struct {
+		name  string
+		build func(b *dagBuilder)
+		err   string
+	}{
+		{
+			name: "operation not ended",
+			build: func(b *dagBuilder) {
+				b.begin(ops[0], TypeProposeCluster, ProposeCluster{Operators: ops})
+				b.begin(ops[0], TypeAcceptClusterBegin, nil)
+			},
+			err: "has not ended",
+		},
+		{
+			name: "does not begin an operation",
+			build: func(b *dagBuilder) {
+				b.add(ops[0], TypeOperatorENR, OperatorENR{ENR: "enr"})
+			},
+			err: "does not begin a new operation",
+		},
+		{
+			name: "operation order",
+			build: func(b *dagBuilder) {
+				b.begin(ops[0], TypeDKG, Validators{})
+			},
+			err: "may not follow",
+		},
+		{
+			name: "begins before previous ended",
+			build: func(b *dagBuilder) {
+				b.begin(ops[0], TypeProposeCluster, ProposeCluster{Operators: ops})
+				b.begin(ops[0], TypeAcceptClusterBegin, nil)
+				b.begin(ops[0], TypeDKG, Validators{})
+			},
+			err: "previous operation has not ended",
+		},
+		{
+			name: "unexpected type",
+			build: func(b *dagBuilder) {
+				b.begin(ops[0], TypeProposeCluster, ProposeCluster{Operators: ops})
+				b.begin(ops[0], TypeAcceptClusterBegin, nil)
+				b.add(ops[0], TypeAcceptClusterEnd, nil)
+			},
+			err: "unexpected type",
+		},
+		{
+			name: "ends before all operators submitted enrs",
+			build: func(b *dagBuilder) {
+				b.begin(ops[0], TypeProposeCluster, ProposeCluster{Operators: ops})
+				b.begin(ops[0], TypeAcceptClusterBegin, nil)
+				b.add(ops[0], TypeOperatorENR, OperatorENR{ENR: "enr"})
+				b.add(ops[0], TypeAcceptClusterEnd, nil)
+			},
+			err: "unexpected type",
+		},
+		{
+			name: "invalid data",
+			build: func(b *dagBuilder) {
+				b.begin(ops[0], TypeProposeCluster, OperatorENR{})
+			},
+			err: "mutation data is not ProposeCluster",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := new(dagBuilder)
+			test.build(b)
+
+			_, err := Materialise(b.dag)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
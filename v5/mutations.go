@@ -3,8 +3,7 @@ package v5
 import "fmt"
 
 var typeDef = map[MutationType]struct {
-	DataType         any
-	ParentOperations []OperationType
+	DataType any
 
 	BeginsOperation OperationType
 	EndsOperation   bool
@@ -15,8 +14,10 @@ var typeDef = map[MutationType]struct {
 	TransformFunc func(ClusterState, SignedMutation) (ClusterState, error)
 }{
 	TypeProposeCluster: {
-		BeginsOperation: OperationAcceptCluster,
+		DataType:        ProposeCluster{},
+		BeginsOperation: OperationProposeCluster,
 		EndsOperation:   true,
+		Spread:          SpreadOne,
 		ValidateFunc: func(state ClusterState, mutation SignedMutation) error {
 			// TODO(corver): validate signed mutation contains valid data
 			// TODO(corver): validate state doesn't contain existing cluster
@@ -24,30 +25,37 @@ var typeDef = map[MutationType]struct {
 			return nil
 		},
 		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
-			state.Name = mutation.Mutation.Data.(ProposeCluster).Name
+			pc, ok := mutation.Mutation.Data.(ProposeCluster)
+			if !ok {
+				return ClusterState{}, fmt.Errorf("mutation data is not ProposeCluster")
+			}
 
-			state.Operators = make([]Operator, len(mutation.Mutation.Data.(ProposeCluster).Operators))
+			state.Name = pc.Name
+
+			state.Operators = make([]Operator, len(pc.Operators))
 			for i := 0; i < len(state.Operators); i++ {
-				state.Operators[i].PublicKey = mutation.Mutation.Data.(ProposeCluster).Operators[i]
+				state.Operators[i].PublicKey = pc.Operators[i]
 			}
 
-			state.Validators = make([]Validator, len(mutation.Mutation.Data.(ProposeCluster).Validators))
+			state.Validators = make([]Validator, len(pc.Validators))
 			// TODO(corver): Add validator feerecipient, withdrawal address, etc etc from to proposal to state.
 
 			return state, nil
 		},
 	},
 	TypeAcceptClusterBegin: {
-		BeginsOperation:  OperationAcceptCluster,
-		EndsOperation:    false,
-		ParentOperations: []OperationType{OperationAcceptCluster},
+		BeginsOperation: OperationAcceptCluster,
+		EndsOperation:   false,
+		Spread:          SpreadOne,
 	},
 	TypeOperatorENR: {
-		BeginsOperation: OperationAcceptCluster,
-		EndsOperation:   true,
-		Spread:          SpreadOperatorsAll,
+		DataType: OperatorENR{},
+		Spread:   SpreadOperatorsAllUnordered,
 		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
-			enr := mutation.Mutation.Data.(OperatorENR).ENR
+			enr, ok := mutation.Mutation.Data.(OperatorENR)
+			if !ok {
+				return ClusterState{}, fmt.Errorf("mutation data is not OperatorENR")
+			}
 
 			for i := 0; i < len(state.Operators); i++ {
 				if state.Operators[i].PublicKey == mutation.Source {
@@ -55,7 +63,7 @@ var typeDef = map[MutationType]struct {
 						return state, fmt.Errorf("operator already has enr")
 					}
 
-					state.Operators[i].ENR = enr
+					state.Operators[i].ENR = enr.ENR
 
 					return state, nil
 				}
@@ -66,28 +74,99 @@ var typeDef = map[MutationType]struct {
 	},
 	TypeAcceptClusterEnd: {
 		EndsOperation: true,
+		Spread:        SpreadOne,
+	},
+	TypeRegisterValidatorsBegin: {
+		BeginsOperation: OperationRegisterValidators,
+		Spread:          SpreadOne,
+	},
+	TypeValidatorRegistration: {
+		DataType: ValidatorRegistration{},
+		Spread:   SpreadValidatorsAll,
+		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
+			reg, ok := mutation.Mutation.Data.(ValidatorRegistration)
+			if !ok {
+				return ClusterState{}, fmt.Errorf("mutation data is not ValidatorRegistration")
+			}
+
+			for i := 0; i < len(state.Validators); i++ {
+				if state.Validators[i].PublicKey == reg.Validator {
+					// Copy the validators since they are shared with the DKG mutation data.
+					state.Validators = append([]Validator(nil), state.Validators...)
+					state.Validators[i].FeeRecipient = reg.FeeRecipient
+
+					return state, nil
+				}
+			}
+
+			return state, fmt.Errorf("validator not found")
+		},
+	},
+	TypeOperatorApproval: {
+		Spread: SpreadOperatorsQuorum,
+	},
+	TypeRegisterValidatorsEnd: {
+		EndsOperation: true,
+		Spread:        SpreadOne,
 	},
 	TypeDKG: {
+		DataType:        Validators{},
 		BeginsOperation: OperationGenerateValidators,
 		EndsOperation:   true,
+		Spread:          SpreadOne,
+		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
+			vals, ok := mutation.Mutation.Data.(Validators)
+			if !ok {
+				return ClusterState{}, fmt.Errorf("mutation data is not Validators")
+			}
+
+			if len(state.Validators) != len(vals) {
+				return ClusterState{}, fmt.Errorf("number of validators does not match number of DKGs")
+			}
+
+			state.Validators = vals
+
+			return state, nil
+		},
 	},
 }
 
 type Spread int
 
 func (s Spread) NewValidatorFunc(state ClusterState) func(SignedMutation) (bool, error) {
-	return spreadDef[s].NewValidatorFunc(state)
+	def, ok := spreadDef[s]
+	if !ok {
+		return func(SignedMutation) (bool, error) {
+			return false, fmt.Errorf("unknown spread: %d", s)
+		}
+	}
+
+	return def.NewValidatorFunc(state)
 }
 
 const (
 	SpreadUnknown Spread = iota
+	// SpreadOne is a single mutation.
 	SpreadOne
+	// SpreadOperatorsAll is a mutation from each operator in operator order.
 	SpreadOperatorsAll
+	// SpreadValidatorsAll is a mutation per validator in any order, the data must implement ValidatorData.
 	SpreadValidatorsAll
+	// SpreadOperatorsQuorum is a mutation from a quorum of operators in any order.
+	SpreadOperatorsQuorum
+	// SpreadOperatorsAllUnordered is a mutation from each operator in any order.
+	SpreadOperatorsAllUnordered
 )
 
+// ValidatorData is implemented by the data of mutations that relate to a single validator.
+type ValidatorData interface {
+	ValidatorPublicKey() PublicKey
+}
+
 var spreadDef = map[Spread]struct {
 	NewValidatorFunc func(ClusterState) func(SignedMutation) (bool, error)
+	// Late allows mutations after the spread is done, which are validated but not applied.
+	Late bool
 }{
 	SpreadOne: {
 		NewValidatorFunc: func(ClusterState) func(SignedMutation) (bool, error) {
@@ -105,7 +184,7 @@ var spreadDef = map[Spread]struct {
 		NewValidatorFunc: func(state ClusterState) func(SignedMutation) (bool, error) {
 			var idx int
 			return func(mutation SignedMutation) (bool, error) {
-				if idx > len(state.Operators) {
+				if idx >= len(state.Operators) {
 					return false, fmt.Errorf("too many mutations")
 				}
 
@@ -119,4 +198,75 @@ var spreadDef = map[Spread]struct {
 			}
 		},
 	},
+	SpreadValidatorsAll: {
+		NewValidatorFunc: func(state ClusterState) func(SignedMutation) (bool, error) {
+			done := make(map[PublicKey]bool)
+			return func(mutation SignedMutation) (bool, error) {
+				data, ok := mutation.Mutation.Data.(ValidatorData)
+				if !ok {
+					return false, fmt.Errorf("mutation data does not reference a validator")
+				}
+
+				key := data.ValidatorPublicKey()
+				if !isValidator(state, key) {
+					return false, fmt.Errorf("mutation for unknown validator")
+				} else if done[key] {
+					return false, fmt.Errorf("duplicate mutation for validator")
+				}
+				done[key] = true
+
+				return len(done) == len(state.Validators), nil
+			}
+		},
+	},
+	SpreadOperatorsQuorum: {
+		NewValidatorFunc: func(state ClusterState) func(SignedMutation) (bool, error) {
+			quorum := (2*len(state.Operators) + 2) / 3 // ceil(2n/3)
+			return newOperatorSetValidator(state, quorum)
+		},
+		Late: true,
+	},
+	SpreadOperatorsAllUnordered: {
+		NewValidatorFunc: func(state ClusterState) func(SignedMutation) (bool, error) {
+			return newOperatorSetValidator(state, len(state.Operators))
+		},
+	},
+}
+
+// newOperatorSetValidator returns a spread validator accepting a mutation from each operator in any order,
+// which is done once the threshold number of operators is reached.
+func newOperatorSetValidator(state ClusterState, threshold int) func(SignedMutation) (bool, error) {
+	done := make(map[PublicKey]bool)
+	return func(mutation SignedMutation) (bool, error) {
+		if !isOperator(state, mutation.Source) {
+			return false, fmt.Errorf("mutation from unknown operator")
+		} else if done[mutation.Source] {
+			return false, fmt.Errorf("duplicate mutation from operator")
+		}
+		done[mutation.Source] = true
+
+		return len(done) >= threshold, nil
+	}
+}
+
+// isOperator returns true if the key is a cluster operator.
+func isOperator(state ClusterState, key PublicKey) bool {
+	for _, op := range state.Operators {
+		if op.PublicKey == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isValidator returns true if the key is a cluster validator.
+func isValidator(state ClusterState, key PublicKey) bool {
+	for _, v := range state.Validators {
+		if v.PublicKey != "" && v.PublicKey == key {
+			return true
+		}
+	}
+
+	return false
 }
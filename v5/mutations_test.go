@@ -0,0 +1,156 @@
+package v5
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpreads(t *testing.T) {
+	ops := newTestOperators(4)
+	state := ClusterState{Validators: newTestValidators(ops, 2)}
+	for _, op := range ops {
+		state.Operators = append(state.Operators, Operator{PublicKey: op})
+	}
+
+	fromOp := func(op PublicKey) SignedMutation {
+		return SignedMutation{Source: op}
+	}
+	forVal := func(val PublicKey) SignedMutation {
+		return SignedMutation{Mutation: Mutation{Data: ValidatorRegistration{Validator: val}}}
+	}
+
+	type call struct {
+		mutation SignedMutation
+		done     bool
+		err      string
+	}
+	tests := []struct {
+		name   string
+		spread Spread
+		calls  []call
+	}{
+		{
+			name:   "one",
+			spread: SpreadOne,
+			calls:  []call{{fromOp(ops[0]), true, ""}, {fromOp(ops[1]), false, "too many"}},
+		},
+		{
+			name:   "operators all in order",
+			spread: SpreadOperatorsAll,
+			calls:  []call{{fromOp(ops[0]), false, ""}, {fromOp(ops[2]), false, "wrong operator"}},
+		},
+		{
+			name:   "operators all unordered",
+			spread: SpreadOperatorsAllUnordered,
+			calls: []call{
+				{fromOp(ops[3]), false, ""}, {fromOp(ops[1]), false, ""}, {fromOp(ops[1]), false, "duplicate"},
+				{fromOp("unknown"), false, "unknown operator"}, {fromOp(ops[0]), false, ""}, {fromOp(ops[2]), true, ""},
+			},
+		},
+		{
+			name:   "operators quorum",
+			spread: SpreadOperatorsQuorum,
+			calls: []call{
+				{fromOp(ops[2]), false, ""}, {fromOp(ops[0]), false, ""}, {fromOp(ops[3]), true, ""},
+				{fromOp(ops[3]), false, "duplicate"}, {fromOp(ops[1]), true, ""},
+			},
+		},
+		{
+			name:   "validators all",
+			spread: SpreadValidatorsAll,
+			calls: []call{
+				{forVal("validator-1"), false, ""}, {forVal("validator-1"), false, "duplicate"},
+				{forVal("unknown"), false, "unknown validator"}, {fromOp(ops[0]), false, "does not reference a validator"},
+				{forVal("validator-0"), true, ""},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validate := test.spread.NewValidatorFunc(state)
+			for i, c := range test.calls {
+				done, err := validate(c.mutation)
+				if c.err != "" {
+					if err == nil || !strings.Contains(err.Error(), c.err) {
+						t.Fatalf("call %d: expected error containing %q, got %v", i, c.err, err)
+					}
+
+					continue
+				} else if err != nil {
+					t.Fatalf("call %d: %v", i, err)
+				} else if done != c.done {
+					t.Fatalf("call %d: expected done=%v", i, c.done)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterValidators(t *testing.T) {
+	ops := newTestOperators(4)
+	vals := newTestValidators(ops, 2)
+
+	t.Run("quorum", func(t *testing.T) {
+		b := &dagBuilder{dag: newTestDAG(ops, 2)}
+		b.operation = b.dag[len(b.dag)-1]
+		b.addTestRegistrations(vals, ops[:3])
+
+		state, err := Materialise(b.dag)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, val := range state.Validators {
+			if val.FeeRecipient != "0x"+string(val.PublicKey) {
+				t.Fatalf("unexpected fee recipient: %+v", val)
+			}
+		}
+
+		// Materialising must not modify the DKG mutation data.
+		for _, sm := range b.dag {
+			if vals, ok := sm.Mutation.Data.(Validators); ok && vals[0].FeeRecipient != "" {
+				t.Fatalf("dkg data modified: %+v", vals)
+			}
+		}
+	})
+
+	t.Run("late approval ignored", func(t *testing.T) {
+		b := &dagBuilder{dag: newTestDAG(ops, 2)}
+		b.operation = b.dag[len(b.dag)-1]
+		b.addTestRegistrations(vals, ops[:3])
+
+		// Insert the last operator's approval after the quorum, before the end mutation.
+		end := b.dag[len(b.dag)-1]
+		b.dag = b.dag[:len(b.dag)-1]
+		b.add(ops[3], TypeOperatorApproval, nil)
+		b.dag = append(b.dag, end)
+
+		if _, err := Materialise(b.dag); err != nil {
+			t.Fatal(err)
+		}
+
+		ops := ListOperations(b.dag)
+		if last := ops[len(ops)-1]; last.Status != StatusComplete || len(last.Sources) != 4 {
+			t.Fatalf("unexpected operation: %+v", last)
+		}
+	})
+
+	t.Run("below quorum", func(t *testing.T) {
+		b := &dagBuilder{dag: newTestDAG(ops, 2)}
+		b.operation = b.dag[len(b.dag)-1]
+		b.addTestRegistrations(vals, ops[:2])
+
+		if _, err := Materialise(b.dag); err == nil || !strings.Contains(err.Error(), "unexpected type") {
+			t.Fatalf("expected unexpected type error, got %v", err)
+		}
+	})
+
+	t.Run("missing validator", func(t *testing.T) {
+		b := &dagBuilder{dag: newTestDAG(ops, 2)}
+		b.operation = b.dag[len(b.dag)-1]
+		b.addTestRegistrations(vals[:1], ops)
+
+		if _, err := Materialise(b.dag); err == nil || !strings.Contains(err.Error(), "unexpected type") {
+			t.Fatalf("expected unexpected type error, got %v", err)
+		}
+	})
+}
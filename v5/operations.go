@@ -0,0 +1,100 @@
+package v5
+
+import "fmt"
+
+// OperationStatus is the status of an operation.
+type OperationStatus string
+
+const (
+	StatusInProgress OperationStatus = "in_progress"
+	StatusComplete   OperationStatus = "complete"
+	StatusFailed     OperationStatus = "failed"
+)
+
+// Operation is a sequence of mutations in the DAG that together change the cluster state.
+type Operation struct {
+	Type   OperationType
+	Status OperationStatus
+	// Err is the reason the operation failed.
+	Err error
+
+	// Begin is the mutation that began the operation.
+	Begin SignedMutation
+	// End is the mutation that ended the operation, nil if not ended.
+	End *SignedMutation
+	// Sources are the participating sources in order of their first mutation.
+	Sources []PublicKey
+
+	// Step is the index of the current mutation type in the operation's sequence.
+	Step int
+	// Steps is the number of mutation types in the operation's sequence.
+	Steps int
+	// StepType is the current mutation type.
+	StepType MutationType
+	// Spread is the spread of the current mutation type.
+	Spread Spread
+	// SpreadCount is the number of mutations of the current type.
+	SpreadCount int
+	// SpreadDone is true if the spread of the current mutation type is complete.
+	SpreadDone bool
+}
+
+// ListOperations returns the operations in the DAG. Listing stops at the first invalid mutation,
+// marking its operation as failed.
+func ListOperations(dag RawDAG) []Operation {
+	var (
+		m    = newMaterialiser()
+		resp []Operation
+	)
+	for i, mutation := range dag {
+		active := m.active
+		err := m.apply(mutation)
+
+		if active == OperationUnknown {
+			// The mutation began (or failed to begin) a new operation.
+			typ, _ := mutation.Mutation.Type.BeginsOperation()
+			resp = append(resp, Operation{
+				Type:   typ,
+				Status: StatusInProgress,
+				Begin:  mutation,
+				Steps:  len(operationDef[typ].Sequence),
+			})
+		}
+
+		op := &resp[len(resp)-1]
+		if !containsKey(op.Sources, mutation.Source) {
+			op.Sources = append(op.Sources, mutation.Source)
+		}
+
+		if err != nil {
+			op.Status = StatusFailed
+			op.Err = fmt.Errorf("mutation %d: %w", i, err)
+
+			break
+		}
+
+		op.Step = m.step
+		op.StepType = mutation.Mutation.Type
+		op.Spread = mutation.Mutation.Type.Spread()
+		op.SpreadCount = m.count
+		op.SpreadDone = m.spreadDone
+
+		if m.active == OperationUnknown {
+			end := mutation
+			op.End = &end
+			op.Status = StatusComplete
+		}
+	}
+
+	return resp
+}
+
+func containsKey(keys []PublicKey, key PublicKey) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
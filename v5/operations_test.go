@@ -0,0 +1,81 @@
+package v5
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListOperations(t *testing.T) {
+	ops := newTestOperators(3)
+	dag := newTestDAG(ops, 2)
+
+	list := ListOperations(dag)
+	if len(list) != 3 {
+		t.Fatalf("expected 3 operations, got %d", len(list))
+	}
+
+	expected := []struct {
+		typ     OperationType
+		sources int
+	}{
+		{OperationProposeCluster, 1},
+		{OperationAcceptCluster, len(ops)},
+		{OperationGenerateValidators, 1},
+	}
+	for i, op := range list {
+		if op.Type != expected[i].typ || op.Status != StatusComplete || op.End == nil {
+			t.Fatalf("unexpected operation %d: %+v", i, op)
+		} else if len(op.Sources) != expected[i].sources {
+			t.Fatalf("operation %d: expected %d sources, got %v", i, expected[i].sources, op.Sources)
+		}
+	}
+
+	accept := list[1]
+	if accept.Begin.Hash != dag[1].Hash || accept.End.Hash != dag[len(ops)+2].Hash {
+		t.Fatal("unexpected accept cluster begin or end")
+	} else if accept.Steps != 3 || accept.StepType != TypeAcceptClusterEnd {
+		t.Fatalf("unexpected accept cluster steps: %+v", accept)
+	}
+}
+
+func TestListOperationsInProgress(t *testing.T) {
+	ops := newTestOperators(3)
+	dag := newTestDAG(ops, 2)[:3] // Propose and the first ENR of accept.
+
+	list := ListOperations(dag)
+	if len(list) != 2 {
+		t.Fatalf("expected 2 operations, got %d", len(list))
+	}
+
+	accept := list[1]
+	if accept.Status != StatusInProgress || accept.End != nil {
+		t.Fatalf("expected in progress: %+v", accept)
+	} else if accept.Step != 1 || accept.StepType != TypeOperatorENR || accept.Spread != SpreadOperatorsAllUnordered {
+		t.Fatalf("unexpected step: %+v", accept)
+	} else if accept.SpreadCount != 1 || accept.SpreadDone {
+		t.Fatalf("unexpected spread progress: %+v", accept)
+	}
+}
+
+func TestListOperationsFailed(t *testing.T) {
+	ops := newTestOperators(3)
+
+	b := new(dagBuilder)
+	b.begin(ops[0], TypeProposeCluster, ProposeCluster{Operators: ops})
+	b.begin(ops[0], TypeAcceptClusterBegin, nil)
+	b.add(ops[1], TypeOperatorENR, OperatorENR{ENR: "enr"})
+	b.add(ops[1], TypeOperatorENR, OperatorENR{ENR: "enr"}) // Duplicate.
+	b.add(ops[0], TypeOperatorENR, OperatorENR{ENR: "enr"}) // Not reached.
+
+	list := ListOperations(b.dag)
+	if len(list) != 2 {
+		t.Fatalf("expected 2 operations, got %d", len(list))
+	}
+
+	accept := list[1]
+	if accept.Status != StatusFailed || accept.Err == nil || !strings.Contains(accept.Err.Error(), "mutation 3") {
+		t.Fatalf("expected failed at mutation 3: %+v", accept)
+	} else if len(accept.Sources) != 2 || accept.SpreadCount != 1 {
+		t.Fatalf("expected listing to stop at the failure: %+v", accept)
+	}
+}
@@ -0,0 +1,95 @@
+package v5
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// SortDAG returns the mutations of an unordered DAG, e.g. as received from peers, in an order
+// that can be materialised. Mutations are sorted topologically by parent mutation and parent
+// operation hashes. Mutations that are ready at the same time are ordered by the position of their
+// type in the operation's sequence, then by hash.
+func SortDAG(dag RawDAG) (RawDAG, error) {
+	byHash := make(map[Hash]SignedMutation)
+	for _, mutation := range dag {
+		if _, ok := byHash[mutation.Hash]; ok {
+			return nil, fmt.Errorf("duplicate mutation hash")
+		} else if mutation.Hash != mutation.Mutation.Hash(mutation.Source) {
+			return nil, fmt.Errorf("invalid mutation hash")
+		}
+		byHash[mutation.Hash] = mutation
+	}
+
+	var (
+		pending  = make(map[Hash]int) // Number of unsorted parents per mutation.
+		children = make(map[Hash][]Hash)
+		ready    []SignedMutation
+	)
+	for _, mutation := range dag {
+		parents := mutation.Mutation.ParentMutationHashes
+		if op := mutation.Mutation.ParentOperationHash; op != (Hash{}) && !containsHash(parents, op) {
+			parents = append(append([]Hash(nil), parents...), op)
+		}
+
+		for _, p := range parents {
+			if _, ok := byHash[p]; !ok {
+				return nil, fmt.Errorf("unknown parent mutation")
+			}
+			children[p] = append(children[p], mutation.Hash)
+		}
+
+		pending[mutation.Hash] = len(parents)
+		if len(parents) == 0 {
+			ready = append(ready, mutation)
+		}
+	}
+
+	var resp RawDAG
+	for len(ready) > 0 {
+		// Select the next ready mutation.
+		next := 0
+		for i := range ready {
+			if sortsBefore(ready[i], ready[next]) {
+				next = i
+			}
+		}
+		mutation := ready[next]
+		ready = append(ready[:next], ready[next+1:]...)
+		resp = append(resp, mutation)
+
+		for _, child := range children[mutation.Hash] {
+			pending[child]--
+			if pending[child] == 0 {
+				ready = append(ready, byHash[child])
+			}
+		}
+	}
+
+	if len(resp) != len(dag) {
+		return nil, fmt.Errorf("cycle detected")
+	}
+
+	return resp, nil
+}
+
+// sortsBefore returns true if a should be materialised before b when both are ready.
+func sortsBefore(a, b SignedMutation) bool {
+	if sa, sb := sequenceIndex(a.Mutation.Type), sequenceIndex(b.Mutation.Type); sa != sb {
+		return sa < sb
+	}
+
+	return bytes.Compare(a.Hash[:], b.Hash[:]) < 0
+}
+
+// sequenceIndex returns the position of the mutation type in its operation's sequence.
+func sequenceIndex(typ MutationType) int {
+	for _, def := range operationDef {
+		for i, t := range def.Sequence {
+			if t == typ {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,128 @@
+package v5
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSortDAG(t *testing.T) {
+	ops := newTestOperators(4)
+	b := &dagBuilder{dag: newTestDAG(ops, 2)}
+	b.operation = b.dag[len(b.dag)-1]
+	b.addTestRegistrations(newTestValidators(ops, 2), ops)
+
+	want, err := Materialise(b.dag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for seed := int64(0); seed < 10; seed++ {
+		shuffled := append(RawDAG(nil), b.dag...)
+		rand.New(rand.NewSource(seed)).Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+
+		sorted, err := SortDAG(shuffled)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+
+		state, err := Materialise(sorted)
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		} else if !reflect.DeepEqual(state, want) {
+			t.Fatalf("seed %d: unexpected state", seed)
+		}
+	}
+}
+
+func TestSortDAGInvalid(t *testing.T) {
+	ops := newTestOperators(2)
+	dag := newTestDAG(ops, 1)
+
+	if _, err := SortDAG(append(dag, dag[1])); err == nil || !strings.Contains(err.Error(), "duplicate") {
+		t.Fatalf("expected duplicate error, got %v", err)
+	}
+
+	if _, err := SortDAG(dag[1:]); err == nil || !strings.Contains(err.Error(), "unknown parent") {
+		t.Fatalf("expected unknown parent error, got %v", err)
+	}
+
+	// A mutation attributed to another source without rehashing is rejected.
+	forged := append(RawDAG(nil), dag...)
+	forged[1].Source = ops[1]
+	if _, err := SortDAG(forged); err == nil || !strings.Contains(err.Error(), "invalid mutation hash") {
+		t.Fatalf("expected invalid hash error, got %v", err)
+	} else if _, err := Materialise(forged); err == nil || !strings.Contains(err.Error(), "invalid mutation hash") {
+		t.Fatalf("expected invalid hash error, got %v", err)
+	}
+}
+
+func TestMutationHashSource(t *testing.T) {
+	// Approvals have no data, so only the source distinguishes approvals of the same operation.
+	m := Mutation{ParentMutationHashes: []Hash{{1}}, Type: TypeOperatorApproval}
+	if m.Hash("operator-0") == m.Hash("operator-1") {
+		t.Fatal("expected hashes of different sources to differ")
+	}
+}
+
+func TestMaterialiseParents(t *testing.T) {
+	ops := newTestOperators(2)
+
+	tests := []struct {
+		name  string
+		build func(b *dagBuilder)
+		err   string
+	}{
+		{
+			name: "unknown parent mutation",
+			build: func(b *dagBuilder) {
+				b.begin(ops[0], TypeProposeCluster, ProposeCluster{Operators: ops})
+				b.append(ops[0], Mutation{
+					ParentMutationHashes: []Hash{{1}},
+					ParentOperationHash:  b.operation.Hash,
+					Type:                 TypeAcceptClusterBegin,
+				})
+			},
+			err: "unknown parent mutation",
+		},
+		{
+			name: "invalid parent operation hash",
+			build: func(b *dagBuilder) {
+				propose := b.begin(ops[0], TypeProposeCluster, ProposeCluster{Operators: ops})
+				b.append(ops[0], Mutation{
+					ParentMutationHashes: []Hash{propose.Hash},
+					Type:                 TypeAcceptClusterBegin,
+				})
+			},
+			err: "invalid parent operation hash",
+		},
+		{
+			name: "does not reference begin mutation",
+			build: func(b *dagBuilder) {
+				b.begin(ops[0], TypeProposeCluster, ProposeCluster{Operators: ops})
+				begin := b.begin(ops[0], TypeAcceptClusterBegin, nil)
+				b.append(ops[0], Mutation{
+					ParentMutationHashes: begin.Mutation.ParentMutationHashes,
+					ParentOperationHash:  begin.Mutation.ParentOperationHash,
+					Type:                 TypeOperatorENR,
+					Data:                 OperatorENR{ENR: "enr"},
+				})
+			},
+			err: "does not reference the operation's begin mutation",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := new(dagBuilder)
+			test.build(b)
+
+			_, err := Materialise(b.dag)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package v5
 import (
 	"crypto/sha256"
 	"encoding/json"
+	"fmt"
 )
 
 // DutyType represents the type of a validator duty; attester, proposer, etc.
@@ -25,7 +26,25 @@ func (t MutationType) EndsOperation() bool {
 }
 
 func (t MutationType) Transform(cl ClusterState, signedMutation SignedMutation) (ClusterState, error) {
-	return typeDef[t].TransformFunc(cl, signedMutation)
+	def, ok := typeDef[t]
+	if !ok {
+		return ClusterState{}, fmt.Errorf("unknown mutation type: %s", t)
+	} else if def.TransformFunc == nil {
+		return cl, nil
+	}
+
+	return def.TransformFunc(cl, signedMutation)
+}
+
+func (t MutationType) Validate(cl ClusterState, signedMutation SignedMutation) error {
+	def, ok := typeDef[t]
+	if !ok {
+		return fmt.Errorf("unknown mutation type: %s", t)
+	} else if def.ValidateFunc == nil {
+		return nil
+	}
+
+	return def.ValidateFunc(cl, signedMutation)
 }
 
 func (t MutationType) Spread() Spread {
@@ -36,6 +55,7 @@ func (t MutationType) Spread() Spread {
 type Validator struct {
 	PublicKey    PublicKey
 	PublicShares []PublicKey
+	FeeRecipient string
 }
 
 const (
@@ -44,6 +64,11 @@ const (
 	TypeAcceptClusterEnd   MutationType = "charon/accept_cluster_end/1.0.0"
 	TypeOperatorENR        MutationType = "charon/operator_enr/1.0.0"
 	TypeDKG                MutationType = "charon/dkg/1.0.0"
+
+	TypeRegisterValidatorsBegin MutationType = "charon/register_validators_begin/1.0.0"
+	TypeValidatorRegistration   MutationType = "charon/validator_registration/1.0.0"
+	TypeOperatorApproval        MutationType = "charon/operator_approval/1.0.0"
+	TypeRegisterValidatorsEnd   MutationType = "charon/register_validators_end/1.0.0"
 )
 
 // SignedMutation represents a mutation signed by the source that created it.
@@ -62,8 +87,13 @@ type Mutation struct {
 	Data                 any
 }
 
-func (m Mutation) Hash() Hash {
-	b, err := json.Marshal(m)
+// Hash returns the hash of the mutation created by the source.
+// The source is included since operators create identical mutations, e.g. approvals without data.
+func (m Mutation) Hash(source PublicKey) Hash {
+	b, err := json.Marshal(struct {
+		Mutation Mutation
+		Source   PublicKey
+	}{m, source})
 	if err != nil {
 		panic(err)
 	}
@@ -81,4 +111,17 @@ type OperatorENR struct {
 	ENR string
 }
 
+type Validators []Validator
+
+// ValidatorRegistration represents the TypeValidatorRegistration mutation data.
+type ValidatorRegistration struct {
+	Validator    PublicKey
+	FeeRecipient string
+}
+
+// ValidatorPublicKey implements ValidatorData.
+func (r ValidatorRegistration) ValidatorPublicKey() PublicKey {
+	return r.Validator
+}
+
 type RawDAG []SignedMutation
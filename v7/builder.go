@@ -0,0 +1,99 @@
+package v5
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CompositeBuilder assembles a parallel composite from child mutations collected from operators in any order,
+// e.g. by a coordinator during a ceremony.
+type CompositeBuilder struct {
+	composite SignedMutation
+	def       TypeDef
+	operators []Operator
+	children  map[PublicKey]SignedMutation
+}
+
+// NewCompositeBuilder returns a builder of the parallel composite of type typ created by the source
+// building on the parent hash. The expected operators are selected from the cluster state the composite
+// will be transformed with, via CompositeDef.ParallelOperators.
+func NewCompositeBuilder(parent Hash, source PublicKey, typ MutationType, state ClusterState) (*CompositeBuilder, error) {
+	def, ok := typeDef[typ]
+	if !ok {
+		return nil, fmt.Errorf("unknown mutation type: %s", typ)
+	} else if def.Composite == nil || def.Composite.Kind != CompositeParallel {
+		return nil, fmt.Errorf("mutation type is not a parallel composite: %s", typ)
+	}
+
+	return &CompositeBuilder{
+		composite: SignedMutation{Mutation: Mutation{Parent: parent, Type: typ}, Source: source},
+		def:       def,
+		operators: def.Composite.ParallelOperators(state),
+		children:  make(map[PublicKey]SignedMutation),
+	}, nil
+}
+
+// Hash returns the header hash of the composite which children must reference as their parent.
+// The hash of the built composite differs since it commits to the children.
+func (b *CompositeBuilder) Hash() Hash {
+	return b.composite.Mutation.HeaderHash(b.composite.Source)
+}
+
+// Add adds a child mutation, returning an error if it is invalid or a duplicate.
+func (b *CompositeBuilder) Add(child SignedMutation) error {
+	if child.Mutation.Type != b.def.Composite.Child {
+		return fmt.Errorf("invalid child type: %s", child.Mutation.Type)
+	} else if child.Mutation.Parent != b.Hash() {
+		return fmt.Errorf("invalid child parent")
+	} else if !isOperator(b.operators, child.Source) {
+		return fmt.Errorf("child from unknown operator: %s", child.Source)
+	} else if _, ok := b.children[child.Source]; ok {
+		return fmt.Errorf("duplicate child from operator: %s", child.Source)
+	}
+
+	b.children[child.Source] = child
+
+	return nil
+}
+
+// Missing returns the operators without a child mutation, in operator order.
+func (b *CompositeBuilder) Missing() []PublicKey {
+	var resp []PublicKey
+	for _, op := range b.operators {
+		if _, ok := b.children[op.PublicKey]; !ok {
+			resp = append(resp, op.PublicKey)
+		}
+	}
+
+	return resp
+}
+
+// Complete returns true if the composite has a child from all operators, or a quorum if allowed.
+func (b *CompositeBuilder) Complete() bool {
+	if b.def.Composite.Quorum {
+		return len(b.children) >= (2*len(b.operators)+2)/3 // ceil(2n/3)
+	}
+
+	return len(b.children) == len(b.operators)
+}
+
+// Build returns the composite with children ordered to match the operators and its hash committing to them.
+func (b *CompositeBuilder) Build() (SignedMutation, error) {
+	if !b.Complete() {
+		return SignedMutation{}, fmt.Errorf("composite incomplete, missing %d operators", len(b.Missing()))
+	}
+
+	typ := reflect.TypeOf(b.def.DataType)
+	data := reflect.MakeSlice(typ, 0, len(b.children))
+	for _, op := range b.operators {
+		if child, ok := b.children[op.PublicKey]; ok {
+			data = reflect.Append(data, reflect.ValueOf(child))
+		}
+	}
+
+	resp := b.composite
+	resp.Mutation.Data = data.Interface()
+	resp.Hash = resp.Mutation.SignedHash(resp.Source)
+
+	return resp, nil
+}
@@ -0,0 +1,111 @@
+package v5
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompositeBuilder(t *testing.T) {
+	ops := newTestOperators(4)
+	state, err := MaterialiseDV(newTestDAG(ops, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewCompositeBuilder(Hash{1}, ops[0], TypeOperatorApprovals, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Children arrive in any order.
+	for _, i := range []int{2, 0, 3} {
+		if err := b.Add(newTestMutation(b.Hash(), ops[i], TypeOperatorApproval, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if b.Complete() {
+		t.Fatal("expected incomplete")
+	} else if missing := b.Missing(); !reflect.DeepEqual(missing, []PublicKey{ops[1]}) {
+		t.Fatalf("unexpected missing: %v", missing)
+	} else if _, err := b.Build(); err == nil || !strings.Contains(err.Error(), "incomplete") {
+		t.Fatalf("expected incomplete error, got %v", err)
+	}
+
+	invalid := []struct {
+		name  string
+		child SignedMutation
+		err   string
+	}{
+		{"duplicate", newTestMutation(b.Hash(), ops[0], TypeOperatorApproval, nil), "duplicate child"},
+		{"unknown operator", newTestMutation(b.Hash(), "unknown", TypeOperatorApproval, nil), "unknown operator"},
+		{"invalid parent", newTestMutation(Hash{}, ops[1], TypeOperatorApproval, nil), "invalid child parent"},
+		{"invalid type", newTestMutation(b.Hash(), ops[1], TypeOperatorENR, nil), "invalid child type"},
+	}
+	for _, test := range invalid {
+		if err := b.Add(test.child); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("%s: expected error containing %q, got %v", test.name, test.err, err)
+		}
+	}
+
+	if err := b.Add(newTestMutation(b.Hash(), ops[1], TypeOperatorApproval, nil)); err != nil {
+		t.Fatal(err)
+	}
+	composite, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	} else if composite.Hash != composite.Mutation.SignedHash(ops[0]) || composite.Hash == b.Hash() {
+		t.Fatal("expected composite hash committing to the children")
+	}
+
+	// The children are ordered to match the operators so the composite is valid.
+	for i, child := range composite.Mutation.Data.(OperatorApprovals) {
+		if child.Source != ops[i] {
+			t.Fatalf("child %d from %s", i, child.Source)
+		}
+	}
+	if _, err := TypeOperatorApprovals.Transform(state.Clone(), composite); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompositeBuilderOperators(t *testing.T) {
+	// New operator ENRs are only expected from the operators selected by the composite definition, i.e. without ENRs.
+	state := ClusterState{Operators: []Operator{
+		{PublicKey: "retained", ENR: "enr://retained"},
+		{PublicKey: "new"},
+	}}
+
+	b, err := NewCompositeBuilder(Hash{}, "retained", TypeNewOperatorENRs, state)
+	if err != nil {
+		t.Fatal(err)
+	} else if missing := b.Missing(); !reflect.DeepEqual(missing, []PublicKey{"new"}) {
+		t.Fatalf("unexpected missing: %v", missing)
+	}
+
+	if err := b.Add(newTestMutation(b.Hash(), "retained", TypeOperatorENR, OperatorENR{ENR: "enr"})); err == nil {
+		t.Fatal("expected error for operator with enr")
+	} else if err := b.Add(newTestMutation(b.Hash(), "new", TypeOperatorENR, OperatorENR{ENR: "enr://new"})); err != nil {
+		t.Fatal(err)
+	}
+
+	composite, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err = TypeNewOperatorENRs.Transform(state, composite)
+	if err != nil {
+		t.Fatal(err)
+	} else if state.Operators[1].ENR != "enr://new" {
+		t.Fatalf("unexpected operators: %+v", state.Operators)
+	}
+}
+
+func TestCompositeBuilderInvalidType(t *testing.T) {
+	if _, err := NewCompositeBuilder(Hash{}, "a", TypeCreateCluster, ClusterState{}); err == nil || !strings.Contains(err.Error(), "not a parallel composite") {
+		t.Fatalf("expected not parallel error, got %v", err)
+	} else if _, err := NewCompositeBuilder(Hash{}, "a", "unknown/type/1.0.0", ClusterState{}); err == nil || !strings.Contains(err.Error(), "unknown mutation type") {
+		t.Fatalf("expected unknown type error, got %v", err)
+	}
+}
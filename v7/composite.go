@@ -0,0 +1,195 @@
+package v5
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CompositeKind is the kind of composite mutation.
+type CompositeKind int
+
+const (
+	CompositeUnknown CompositeKind = iota
+	// CompositeLinear is a fixed sequence of child mutations of the expected types.
+	CompositeLinear
+	// CompositeParallel is a child mutation of the same type per operator, in operator order.
+	CompositeParallel
+)
+
+// CompositeDef defines a composite mutation type whose data is an array or slice of child mutations.
+// Composites are validated and transformed by the framework, transforming each child in order.
+type CompositeDef struct {
+	Kind CompositeKind
+	// Children are the expected child types of a linear composite.
+	Children []MutationType
+	// Optional is the number of trailing children of a linear composite that may be omitted, it requires a slice data type.
+	Optional int
+	// Child is the expected child type of a parallel composite.
+	Child MutationType
+	// Quorum allows a parallel composite to contain children from only a quorum of operators.
+	Quorum bool
+	// Operators optionally selects the operators of a parallel composite, it defaults to all cluster operators.
+	Operators func(ClusterState) []Operator
+}
+
+// ParallelOperators returns the operators expected to create the children of a parallel composite.
+func (d CompositeDef) ParallelOperators(state ClusterState) []Operator {
+	if d.Operators != nil {
+		return d.Operators(state)
+	}
+
+	return state.Operators
+}
+
+// verify returns an error if the definition is invalid for the data type.
+func (d CompositeDef) verify(dataType any) error {
+	typ := reflect.TypeOf(dataType)
+	if typ == nil || (typ.Kind() != reflect.Array && typ.Kind() != reflect.Slice) || typ.Elem() != reflect.TypeOf(SignedMutation{}) {
+		return fmt.Errorf("composite data type must be an array or slice of signed mutations")
+	}
+
+	switch d.Kind {
+	case CompositeLinear:
+		if len(d.Children) == 0 {
+			return fmt.Errorf("linear composite missing children")
+		} else if typ.Kind() == reflect.Array && typ.Len() != len(d.Children) {
+			return fmt.Errorf("linear composite data type length does not match children")
+		} else if d.Optional < 0 || d.Optional >= len(d.Children) {
+			return fmt.Errorf("invalid number of optional linear composite children")
+		} else if d.Optional > 0 && typ.Kind() != reflect.Slice {
+			return fmt.Errorf("linear composite with optional children must have a slice data type")
+		}
+	case CompositeParallel:
+		if d.Child == "" {
+			return fmt.Errorf("parallel composite missing child")
+		} else if typ.Kind() != reflect.Slice {
+			return fmt.Errorf("parallel composite data type must be a slice")
+		}
+	default:
+		return fmt.Errorf("unknown composite kind")
+	}
+
+	return nil
+}
+
+// transformComposite validates the composite mutation and transforms each child in order.
+func transformComposite(def TypeDef, state ClusterState, mutation SignedMutation) (ClusterState, error) {
+	children, err := compositeChildren(def.DataType, mutation)
+	if err != nil {
+		return ClusterState{}, err
+	}
+
+	header := mutation.Mutation.HeaderHash(mutation.Source)
+	for i, child := range children {
+		if child.Mutation.Parent != header {
+			return ClusterState{}, fmt.Errorf("mutation %d has invalid parent", i)
+		}
+	}
+
+	switch def.Composite.Kind {
+	case CompositeLinear:
+		if err := verifyLinear(children, def.Composite.Children, def.Composite.Optional); err != nil {
+			return ClusterState{}, fmt.Errorf("invalid linear composite: %w", err)
+		}
+	case CompositeParallel:
+		if err := verifyParallel(children, def.Composite.Child, def.Composite.Quorum, def.Composite.ParallelOperators(state)); err != nil {
+			return ClusterState{}, fmt.Errorf("invalid parallel composite: %w", err)
+		}
+	default:
+		return ClusterState{}, fmt.Errorf("unknown composite kind")
+	}
+
+	for _, child := range children {
+		state, err = child.Mutation.Type.Transform(state, child)
+		if err != nil {
+			return ClusterState{}, fmt.Errorf("transform mutation: %w", err)
+		}
+	}
+
+	return state, nil
+}
+
+// compositeChildren returns the child mutations of the composite.
+func compositeChildren(dataType any, mutation SignedMutation) ([]SignedMutation, error) {
+	if mutation.Mutation.Data == nil || reflect.TypeOf(mutation.Mutation.Data) != reflect.TypeOf(dataType) {
+		return nil, fmt.Errorf("mutation data is not %T", dataType)
+	}
+
+	val := reflect.ValueOf(mutation.Mutation.Data)
+	resp := make([]SignedMutation, val.Len())
+	for i := range resp {
+		resp[i] = val.Index(i).Interface().(SignedMutation)
+	}
+
+	return resp, nil
+}
+
+// verifyHashes returns an error if the hash of the mutation or of any of its descendants does not match its content.
+// Since composite hashes commit to the ordered child hashes, this also verifies the children are not modified.
+func verifyHashes(mutation SignedMutation) error {
+	if mutation.Hash != mutation.Mutation.SignedHash(mutation.Source) {
+		return fmt.Errorf("invalid hash of %s", mutation.Mutation.Type)
+	}
+
+	def, ok := typeDef[mutation.Mutation.Type]
+	if !ok || def.Composite == nil {
+		return nil
+	}
+
+	children, err := compositeChildren(def.DataType, mutation)
+	if err != nil {
+		return nil // Invalid composite data is rejected by transformComposite.
+	}
+
+	for i, child := range children {
+		if err := verifyHashes(child); err != nil {
+			return fmt.Errorf("child %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// verifyLinear returns an error if the children are not of the expected types, excluding omitted optional children.
+func verifyLinear(children []SignedMutation, types []MutationType, optional int) error {
+	if len(children) > len(types) || len(children) < len(types)-optional {
+		return fmt.Errorf("expected %d mutations", len(types))
+	}
+
+	for i, child := range children {
+		if child.Mutation.Type != types[i] {
+			return fmt.Errorf("mutation %d is not %s", i, types[i])
+		}
+	}
+
+	return nil
+}
+
+// verifyParallel returns an error if the children are not of the expected type from each operator (or a quorum) in operator order.
+func verifyParallel(children []SignedMutation, typ MutationType, quorum bool, operators []Operator) error {
+	if !quorum && len(children) != len(operators) {
+		return fmt.Errorf("number of parallel mutations does not match number of operators")
+	} else if quorum && len(children) < (2*len(operators)+2)/3 { // ceil(2n/3)
+		return fmt.Errorf("number of parallel mutations less than quorum of operators")
+	}
+
+	var idx int
+	for i, child := range children {
+		if child.Mutation.Type != typ {
+			return fmt.Errorf("mutation %d has invalid type", i)
+		}
+
+		for idx < len(operators) && operators[idx].PublicKey != child.Source {
+			if !quorum {
+				return fmt.Errorf("mutation %d has invalid source", i)
+			}
+			idx++
+		}
+		if idx == len(operators) {
+			return fmt.Errorf("mutation %d has invalid source", i)
+		}
+		idx++
+	}
+
+	return nil
+}
@@ -0,0 +1,209 @@
+package v5
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyLinear(t *testing.T) {
+	types := []MutationType{TypeProposeExit, TypeOperatorApprovals, TypeExitMessages}
+	children := []SignedMutation{
+		newTestMutation(Hash{}, "a", TypeProposeExit, nil),
+		newTestMutation(Hash{}, "a", TypeOperatorApprovals, nil),
+		newTestMutation(Hash{}, "a", TypeExitMessages, nil),
+	}
+
+	tests := []struct {
+		name     string
+		children []SignedMutation
+		optional int
+		err      string
+	}{
+		{name: "all", children: children},
+		{name: "all with optional", children: children, optional: 1},
+		{name: "optional omitted", children: children[:2], optional: 1},
+		{name: "missing", children: children[:2], err: "expected 3 mutations"},
+		{name: "too many omitted", children: children[:1], optional: 1, err: "expected 3 mutations"},
+		{name: "too many", children: append(children[:3:3], children[0]), err: "expected 3 mutations"},
+		{name: "wrong order", children: []SignedMutation{children[1], children[0], children[2]}, err: "mutation 0 is"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifyLinear(test.children, types, test.optional)
+			if test.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestVerifyParallel(t *testing.T) {
+	keys := newTestOperators(4)
+	var ops []Operator
+	for _, key := range keys {
+		ops = append(ops, Operator{PublicKey: key})
+	}
+	children := func(keys ...PublicKey) []SignedMutation {
+		return newTestChildren(Hash{}, TypeOperatorApproval, keys, nil)
+	}
+
+	tests := []struct {
+		name     string
+		children []SignedMutation
+		quorum   bool
+		err      string
+	}{
+		{name: "all", children: children(keys...)},
+		{name: "missing", children: children(keys[:3]...), err: "does not match number of operators"},
+		{name: "out of order", children: children(keys[1], keys[0], keys[2], keys[3]), err: "invalid source"},
+		{name: "unknown operator", children: children(keys[0], keys[1], keys[2], "unknown"), err: "invalid source"},
+		{name: "invalid type", children: append(children(keys[:3]...), newTestMutation(Hash{}, keys[3], TypeOperatorENR, nil)), err: "invalid type"},
+		{name: "quorum all", children: children(keys...), quorum: true},
+		{name: "quorum", children: children(keys[0], keys[2], keys[3]), quorum: true},
+		{name: "below quorum", children: children(keys[0], keys[3]), quorum: true, err: "less than quorum"},
+		{name: "quorum out of order", children: children(keys[2], keys[0], keys[3]), quorum: true, err: "invalid source"},
+		{name: "quorum duplicate", children: children(keys[0], keys[0], keys[1]), quorum: true, err: "invalid source"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := verifyParallel(test.children, TypeOperatorApproval, test.quorum, ops)
+			if test.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestTransformComposite(t *testing.T) {
+	ops := newTestOperators(3)
+	dag := newTestDAG(ops, 1)
+	state, err := MaterialiseDV(dag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newAddValidators := func(approvals func(parent Hash) SignedMutation) SignedMutation {
+		return newTestComposite(dag[1].Hash, ops[0], TypeAddValidators, func(header Hash) any {
+			return AddValidators{
+				newTestMutation(header, ops[0], TypeProposeValidators, newTestValidators(1, 1, ops)),
+				approvals(header),
+			}
+		})
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		next, err := TypeAddValidators.Transform(state.Clone(), newAddValidators(func(parent Hash) SignedMutation {
+			return newTestApprovals(parent, ops)
+		}))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(next.Validators) != 2 {
+			t.Fatalf("expected 2 validators, got %d", len(next.Validators))
+		}
+	})
+
+	t.Run("invalid child parent", func(t *testing.T) {
+		_, err := TypeAddValidators.Transform(state.Clone(), newAddValidators(func(Hash) SignedMutation {
+			return newTestApprovals(Hash{}, ops)
+		}))
+		if err == nil || !strings.Contains(err.Error(), "mutation 1 has invalid parent") {
+			t.Fatalf("expected invalid parent error, got %v", err)
+		}
+	})
+
+	t.Run("invalid grandchild parent", func(t *testing.T) {
+		_, err := TypeAddValidators.Transform(state.Clone(), newAddValidators(func(parent Hash) SignedMutation {
+			return newTestComposite(parent, ops[0], TypeOperatorApprovals, func(Hash) any {
+				return OperatorApprovals(newTestChildren(parent, TypeOperatorApproval, ops, nil))
+			})
+		}))
+		if err == nil || !strings.Contains(err.Error(), "invalid parent") {
+			t.Fatalf("expected invalid parent error, got %v", err)
+		}
+	})
+
+	t.Run("wrong data type", func(t *testing.T) {
+		mutation := newTestMutation(dag[1].Hash, ops[0], TypeAddValidators, ReshareValidators{})
+		if _, err := TypeAddValidators.Transform(state.Clone(), mutation); err == nil || !strings.Contains(err.Error(), "mutation data is not") {
+			t.Fatalf("expected data type error, got %v", err)
+		}
+	})
+}
+
+func TestRegisterComposite(t *testing.T) {
+	// A quorum composite of approvals from operators with ENRs, defined in a few lines.
+	const typ MutationType = "test/quorum_approvals/1.0.0"
+	err := RegisterMutationType(typ, TypeDef{
+		DataType: OperatorApprovals{},
+		Composite: &CompositeDef{
+			Kind:   CompositeParallel,
+			Child:  TypeOperatorApproval,
+			Quorum: true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { delete(typeDef, typ) })
+
+	ops := newTestOperators(4)
+	state, err := MaterialiseDV(newTestDAG(ops, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quorum := newTestComposite(Hash{}, ops[0], typ, func(header Hash) any {
+		return OperatorApprovals(newTestChildren(header, TypeOperatorApproval, ops[1:], nil))
+	})
+	if _, err := typ.Transform(state.Clone(), quorum); err != nil {
+		t.Fatal(err)
+	}
+
+	below := newTestComposite(Hash{}, ops[0], typ, func(header Hash) any {
+		return OperatorApprovals(newTestChildren(header, TypeOperatorApproval, ops[2:], nil))
+	})
+	if _, err := typ.Transform(state.Clone(), below); err == nil || !strings.Contains(err.Error(), "less than quorum") {
+		t.Fatalf("expected quorum error, got %v", err)
+	}
+}
+
+func TestCompositeSignedHash(t *testing.T) {
+	ops := newTestOperators(3)
+	create := newTestCreateCluster(ops, 1)
+	data := create.Mutation.Data.(CreateCluster)
+	header := create.Mutation.HeaderHash(create.Source)
+
+	if create.Hash == header {
+		t.Fatal("expected composite hash to differ from the header hash")
+	} else if data[0].Mutation.Parent != header {
+		t.Fatal("expected children to reference the header hash")
+	}
+
+	// Modifying a grandchild changes the hash of the composite but not its header hash.
+	enrs := append(OperatorENRs(nil), data[1].Mutation.Data.(OperatorENRs)...)
+	enrs[0] = newTestMutation(enrs[0].Mutation.Parent, ops[0], TypeOperatorENR, OperatorENR{ENR: "enr://forged"})
+	forged := data
+	forged[1].Mutation.Data = enrs
+
+	m := create.Mutation
+	m.Data = forged
+	if m.SignedHash(create.Source) == create.Hash {
+		t.Fatal("expected hash to commit to grandchildren")
+	} else if m.HeaderHash(create.Source) != header {
+		t.Fatal("expected header hash to exclude data")
+	}
+
+	// Reordering children changes the hash.
+	approvals := newTestApprovals(Hash{}, ops)
+	reordered := append(OperatorApprovals(nil), approvals.Mutation.Data.(OperatorApprovals)...)
+	reordered[0], reordered[1] = reordered[1], reordered[0]
+	m = approvals.Mutation
+	m.Data = reordered
+	if m.SignedHash(approvals.Source) == approvals.Hash {
+		t.Fatal("expected hash to commit to the order of children")
+	}
+}
@@ -0,0 +1,163 @@
+package v5
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fuzzMutation is the JSON encoding of a SignedMutation with data decoded according to its type.
+// Composite data is encoded as a list of nested fuzzMutations.
+type fuzzMutation struct {
+	Hash   Hash
+	Source PublicKey
+	Parent Hash
+	Type   MutationType
+	Data   json.RawMessage
+}
+
+func FuzzMaterialiseDV(f *testing.F) {
+	f.Add([]byte(`[]`))
+	f.Add([]byte(`[{"Type":"charon/create_cluster/1.0.0","Data":[]}]`))
+	f.Add([]byte(`[{"Type":"charon/create_cluster/1.0.0","Data":[{"Type":"charon/propose_cluster/1.0.0","Data":{}},{"Type":"charon/operator_enrs/1.0.0","Data":[]}]}]`))
+
+	dag := newTestDAG(newTestOperators(4), 2)
+	for i := 1; i <= len(dag); i++ {
+		f.Add(fuzzEncode(f, dag[:i]))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var mutations []fuzzMutation
+		if err := json.Unmarshal(data, &mutations); err != nil {
+			return
+		}
+
+		var dag RawDAG
+		for _, m := range mutations {
+			sm, err := m.decode()
+			if err != nil {
+				return
+			}
+			dag = append(dag, sm)
+		}
+
+		_, _ = MaterialiseDV(dag)
+	})
+}
+
+// fuzzEncode returns the JSON encoding of the DAG as a list of fuzzMutations.
+func fuzzEncode(tb testing.TB, dag RawDAG) []byte {
+	var mutations []fuzzMutation
+	for _, sm := range dag {
+		mutations = append(mutations, newFuzzMutation(tb, sm))
+	}
+
+	b, err := json.Marshal(mutations)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return b
+}
+
+// newFuzzMutation returns the fuzzMutation of the signed mutation, encoding composite children recursively.
+func newFuzzMutation(tb testing.TB, sm SignedMutation) fuzzMutation {
+	var data any = sm.Mutation.Data
+	if sm.Mutation.Type.Composite() {
+		var nested []fuzzMutation
+		val := reflect.ValueOf(sm.Mutation.Data)
+		for i := 0; i < val.Len(); i++ {
+			nested = append(nested, newFuzzMutation(tb, val.Index(i).Interface().(SignedMutation)))
+		}
+		data = nested
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return fuzzMutation{
+		Hash:   sm.Hash,
+		Source: sm.Source,
+		Parent: sm.Mutation.Parent,
+		Type:   sm.Mutation.Type,
+		Data:   b,
+	}
+}
+
+// decode returns the signed mutation with typed data.
+func (m fuzzMutation) decode() (SignedMutation, error) {
+	var data any
+	if def, ok := typeDef[m.Type]; ok && def.DataType != nil {
+		typ := reflect.TypeOf(def.DataType)
+		if m.Type.Composite() {
+			var nested []fuzzMutation
+			if err := json.Unmarshal(m.Data, &nested); err != nil {
+				return SignedMutation{}, err
+			}
+
+			val := reflect.New(typ).Elem()
+			if typ.Kind() == reflect.Slice {
+				val.Set(reflect.MakeSlice(typ, len(nested), len(nested)))
+			} else if len(nested) != typ.Len() {
+				return SignedMutation{}, fmt.Errorf("invalid composite length")
+			}
+
+			for i, n := range nested {
+				sm, err := n.decode()
+				if err != nil {
+					return SignedMutation{}, err
+				}
+				val.Index(i).Set(reflect.ValueOf(sm))
+			}
+			data = val.Interface()
+		} else {
+			ptr := reflect.New(typ)
+			if err := json.Unmarshal(m.Data, ptr.Interface()); err != nil {
+				return SignedMutation{}, err
+			}
+			data = ptr.Elem().Interface()
+		}
+	} else if len(m.Data) > 0 {
+		if err := json.Unmarshal(m.Data, &data); err != nil {
+			return SignedMutation{}, err
+		}
+	}
+
+	return SignedMutation{
+		Mutation: Mutation{
+			Parent: m.Parent,
+			Type:   m.Type,
+			Data:   data,
+		},
+		Hash:   m.Hash,
+		Source: m.Source,
+	}, nil
+}
+
+func TestFuzzEncodeRoundTrip(t *testing.T) {
+	dag := newTestDAG(newTestOperators(4), 2)
+
+	var mutations []fuzzMutation
+	if err := json.Unmarshal(fuzzEncode(t, dag), &mutations); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded RawDAG
+	for _, m := range mutations {
+		sm, err := m.decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded = append(decoded, sm)
+	}
+
+	// The seed corpus must materialise, otherwise the fuzzer starts from rejected inputs only.
+	if _, err := MaterialiseDV(decoded); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(dag, decoded) {
+		t.Fatal("decoded DAG does not match")
+	}
+}
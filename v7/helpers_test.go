@@ -0,0 +1,169 @@
+package v5
+
+import "fmt"
+
+// newTestMutation returns the mutation created by the source building on the parent.
+// Composite data must not be modified afterwards since the hash commits to the children, see newTestComposite.
+func newTestMutation(parent Hash, source PublicKey, typ MutationType, data any) SignedMutation {
+	m := Mutation{Parent: parent, Type: typ, Data: data}
+
+	return SignedMutation{Mutation: m, Hash: m.SignedHash(source), Source: source}
+}
+
+// newTestComposite returns the composite created by the source building on the parent, with the
+// children returned by the function given the header hash they must reference as their parent.
+func newTestComposite(parent Hash, source PublicKey, typ MutationType, children func(header Hash) any) SignedMutation {
+	m := Mutation{Parent: parent, Type: typ}
+	m.Data = children(m.HeaderHash(source))
+
+	return SignedMutation{Mutation: m, Hash: m.SignedHash(source), Source: source}
+}
+
+// resealTest returns the composite with its hash recomputed after its data was modified.
+func resealTest(composite SignedMutation) SignedMutation {
+	composite.Hash = composite.Mutation.SignedHash(composite.Source)
+
+	return composite
+}
+
+// newTestChildren returns a child mutation per operator building on the parent.
+func newTestChildren(parent Hash, typ MutationType, ops []PublicKey, data func(i int) any) []SignedMutation {
+	var resp []SignedMutation
+	for i, op := range ops {
+		var d any
+		if data != nil {
+			d = data(i)
+		}
+		resp = append(resp, newTestMutation(parent, op, typ, d))
+	}
+
+	return resp
+}
+
+// newTestCreateCluster returns a create cluster composite with ENRs from all operators.
+func newTestCreateCluster(ops []PublicKey, numValidators int) SignedMutation {
+	return newTestComposite(Hash{}, ops[0], TypeCreateCluster, func(header Hash) any {
+		enrs := newTestComposite(header, ops[0], TypeOperatorENRs, func(header Hash) any {
+			return OperatorENRs(newTestChildren(header, TypeOperatorENR, ops, func(i int) any {
+				return OperatorENR{ENR: fmt.Sprintf("enr://%s", ops[i])}
+			}))
+		})
+
+		return CreateCluster{
+			newTestMutation(header, ops[0], TypeProposeCluster, ProposeCluster{
+				Name:       "test",
+				Operators:  ops,
+				Validators: make([]Validator, numValidators),
+			}),
+			enrs,
+		}
+	})
+}
+
+// newTestGenerateValidators returns a generate validators composite acknowledged by all operators.
+func newTestGenerateValidators(parent Hash, ops []PublicKey, vals Validators) SignedMutation {
+	return newTestComposite(parent, ops[0], TypeGenerateValidators, func(header Hash) any {
+		return GenerateValidators{
+			newTestMutation(header, ops[0], TypeDKG, vals),
+			newTestAcks(header, ops, vals),
+		}
+	})
+}
+
+// newTestAcks returns a validator acks composite acknowledging each operator's shares of the validators.
+func newTestAcks(parent Hash, ops []PublicKey, vals Validators) SignedMutation {
+	return newTestComposite(parent, ops[0], TypeValidatorAcks, func(header Hash) any {
+		return ValidatorAcks(newTestChildren(header, TypeValidatorAck, ops, func(i int) any {
+			var ack ValidatorAck
+			for _, val := range vals {
+				ack.Shares = append(ack.Shares, ShareAck{
+					ValidatorPublicKey: val.PublicKey,
+					ShareCommitment:    ShareCommitment(val.PublicKey, ops[i], val.PublicShares[i]),
+				})
+			}
+
+			return ack
+		}))
+	})
+}
+
+// newTestApprovals returns an operator approvals composite with an approval from each operator.
+func newTestApprovals(parent Hash, ops []PublicKey) SignedMutation {
+	return newTestComposite(parent, ops[0], TypeOperatorApprovals, func(header Hash) any {
+		return OperatorApprovals(newTestChildren(header, TypeOperatorApproval, ops, nil))
+	})
+}
+
+// newTestValidators returns deterministic validators with a share per operator, starting at index from.
+func newTestValidators(from, n int, ops []PublicKey) Validators {
+	var resp Validators
+	for i := from; i < from+n; i++ {
+		v := Validator{PublicKey: PublicKey(fmt.Sprintf("validator-%d", i))}
+		for _, op := range ops {
+			v.PublicShares = append(v.PublicShares, PublicKey(fmt.Sprintf("validator-%d/share-%s", i, op)))
+		}
+		resp = append(resp, v)
+	}
+
+	return resp
+}
+
+func newTestOperators(n int) []PublicKey {
+	var resp []PublicKey
+	for i := 0; i < n; i++ {
+		resp = append(resp, PublicKey(fmt.Sprintf("operator-%d", i)))
+	}
+
+	return resp
+}
+
+// newTestDAG returns a DAG creating a cluster and generating its validators.
+func newTestDAG(ops []PublicKey, numValidators int) RawDAG {
+	create := newTestCreateCluster(ops, numValidators)
+	generate := newTestGenerateValidators(create.Hash, ops, newTestValidators(0, numValidators, ops))
+
+	return RawDAG{create, generate}
+}
+
+// newTestReplaceOperators returns a replace operators composite approved by the current operators,
+// with ENRs from the new operators and the validators reshared to the new operators, who acknowledge their shares.
+func newTestReplaceOperators(parent Hash, ops, newOps []PublicKey, vals Validators) SignedMutation {
+	var added []PublicKey
+	for _, op := range newOps {
+		if !containsKey(ops, op) {
+			added = append(added, op)
+		}
+	}
+
+	return newTestComposite(parent, ops[0], TypeReplaceOperators, func(header Hash) any {
+		enrs := newTestComposite(header, ops[0], TypeNewOperatorENRs, func(header Hash) any {
+			return NewOperatorENRs(newTestChildren(header, TypeOperatorENR, added, func(i int) any {
+				return OperatorENR{ENR: fmt.Sprintf("enr://%s", added[i])}
+			}))
+		})
+
+		return ReplaceOperators{
+			newTestApprovals(header, ops),
+			newTestMutation(header, ops[0], TypeProposeOperators, ProposeOperators{Operators: newOps}),
+			enrs,
+			newTestMutation(header, ops[0], TypeReshare, vals),
+			newTestAcks(header, newOps, vals),
+		}
+	})
+}
+
+// newTestExitValidators returns an exit validators composite approved by all operators,
+// with the exit messages if any.
+func newTestExitValidators(parent Hash, ops []PublicKey, vals []PublicKey, msgs ExitMessages) SignedMutation {
+	return newTestComposite(parent, ops[0], TypeExitValidators, func(header Hash) any {
+		data := ExitValidators{
+			newTestMutation(header, ops[0], TypeProposeExit, ProposeExit{Validators: vals}),
+			newTestApprovals(header, ops),
+		}
+		if msgs != nil {
+			data = append(data, newTestMutation(header, ops[0], TypeExitMessages, msgs))
+		}
+
+		return data
+	})
+}
@@ -1,41 +1,119 @@
 package v5
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+)
 
+// MaterialiseDV returns the cluster state resulting from the RawDAG.
 func MaterialiseDV(dag RawDAG) (ClusterState, error) {
-	var (
-		state   ClusterState
-		parent  Hash
-		allowed = map[MutationType]bool{
-			TypeCreateCluster:      true,
-			TypeGenerateValidators: true,
-			TypeAddValidators:      true,
-		}
-	)
-	for i, mutation := range dag {
-		// TypeCreateCluster is first, then one or more others
-		if i == 0 && mutation.Mutation.Type != TypeCreateCluster {
-			return ClusterState{}, fmt.Errorf("first mutation must be TypeCreateCluster")
-		} else if i != 0 && mutation.Mutation.Type == TypeCreateCluster {
-			return ClusterState{}, fmt.Errorf("mutation %d is TypeCreateCluster", i)
+	m := NewMaterialiser()
+	for _, mutation := range dag {
+		if err := m.Apply(mutation); err != nil {
+			return ClusterState{}, err
 		}
+	}
 
-		if !allowed[mutation.Mutation.Type] {
-			return ClusterState{}, fmt.Errorf("mutation %d is not allowed", i)
-		}
+	return m.State(), nil
+}
 
-		if mutation.Mutation.Parent != parent {
-			return ClusterState{}, fmt.Errorf("mutation %d has invalid parent", i)
-		}
+// Materialiser materialises the cluster state incrementally, one top-level composite at a time.
+// It can be checkpointed and resumed to avoid re-applying the whole RawDAG.
+type Materialiser struct {
+	state ClusterState
+	head  Hash // Hash of the last applied composite.
+	count int  // Number of applied composites.
+}
 
-		var err error
-		state, err = mutation.Mutation.Type.Transform(state, mutation)
-		if err != nil {
-			return ClusterState{}, fmt.Errorf("transform mutation: %w", err)
-		}
+// NewMaterialiser returns a materialiser of an empty RawDAG.
+func NewMaterialiser() *Materialiser {
+	return &Materialiser{}
+}
+
+// ResumeMaterialiser returns a materialiser resumed from the checkpoint.
+func ResumeMaterialiser(checkpoint []byte) (*Materialiser, error) {
+	var c materialiserCheckpoint
+	if err := json.Unmarshal(checkpoint, &c); err != nil {
+		return nil, fmt.Errorf("unmarshal checkpoint: %w", err)
+	} else if c.Count < 0 || (c.Count == 0) != (c.Head == Hash{}) {
+		return nil, fmt.Errorf("invalid checkpoint")
+	}
 
-		parent = mutation.Hash
+	return &Materialiser{state: c.State, head: c.Head, count: c.Count}, nil
+}
+
+// materialiserCheckpoint is the serialised materialiser.
+type materialiserCheckpoint struct {
+	State ClusterState
+	Head  Hash
+	Count int
+}
+
+// Checkpoint returns the serialised materialiser which may be resumed via ResumeMaterialiser.
+func (m *Materialiser) Checkpoint() ([]byte, error) {
+	b, err := json.Marshal(materialiserCheckpoint{State: m.state, Head: m.head, Count: m.count})
+	if err != nil {
+		return nil, fmt.Errorf("marshal checkpoint: %w", err)
+	}
+
+	return b, nil
+}
+
+// State returns a copy of the current cluster state.
+func (m *Materialiser) State() ClusterState {
+	return m.state.Clone()
+}
+
+// Head returns the hash of the last applied composite, which the next composite must reference as its parent.
+func (m *Materialiser) Head() Hash {
+	return m.head
+}
+
+// Apply validates and applies the next top-level composite. The state is unchanged if an error is returned.
+func (m *Materialiser) Apply(mutation SignedMutation) error {
+	i := m.count
+
+	// TypeCreateCluster is first, then one or more others
+	if i == 0 && mutation.Mutation.Type != TypeCreateCluster {
+		return fmt.Errorf("first mutation must be TypeCreateCluster")
+	} else if i != 0 && mutation.Mutation.Type == TypeCreateCluster {
+		return fmt.Errorf("mutation %d is TypeCreateCluster", i)
+	}
+
+	if !mutation.Mutation.Type.TopLevel() {
+		return fmt.Errorf("mutation %d is not allowed", i)
+	}
+
+	if mutation.Mutation.Parent != m.head {
+		return fmt.Errorf("mutation %d has invalid parent", i)
+	} else if err := verifyHashes(mutation); err != nil {
+		return fmt.Errorf("mutation %d: %w", i, err)
+	}
+
+	// Transforms may modify the state in place, so transform a copy.
+	state, err := mutation.Mutation.Type.Transform(m.state.Clone(), mutation)
+	if err != nil {
+		return fmt.Errorf("transform mutation: %w", err)
+	}
+
+	if err := verifyNotExited(state); err != nil {
+		return fmt.Errorf("mutation %d: %w", i, err)
+	}
+
+	m.state = state
+	m.head = mutation.Hash
+	m.count++
+
+	return nil
+}
+
+// verifyNotExited returns an error if an exited validator is referenced by the cluster's validators.
+func verifyNotExited(state ClusterState) error {
+	for _, val := range state.Validators {
+		if containsKey(state.ExitedValidators, val.PublicKey) {
+			return fmt.Errorf("exited validator referenced: %s", val.PublicKey)
+		}
 	}
 
-	return state, nil
+	return nil
 }
@@ -0,0 +1,155 @@
+package v5
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMaterialiser(t *testing.T) {
+	ops := newTestOperators(3)
+	dag := newTestDAG(ops, 2)
+	exit := newTestExitValidators(dag[1].Hash, ops, []PublicKey{"validator-0"}, nil)
+	dag = append(dag, exit)
+
+	want, err := MaterialiseDV(dag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Checkpoint and resume after each composite.
+	m := NewMaterialiser()
+	for i, mutation := range dag {
+		checkpoint, err := m.Checkpoint()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m, err = ResumeMaterialiser(checkpoint)
+		if err != nil {
+			t.Fatalf("resume %d: %v", i, err)
+		} else if err := m.Apply(mutation); err != nil {
+			t.Fatalf("apply %d: %v", i, err)
+		} else if m.Head() != mutation.Hash {
+			t.Fatalf("apply %d: unexpected head", i)
+		}
+	}
+
+	if !reflect.DeepEqual(m.State(), want) {
+		t.Fatalf("unexpected state: %+v", m.State())
+	}
+
+	// The returned state is a copy.
+	state := m.State()
+	state.Operators[0].ENR = "modified"
+	state.ExitedValidators[0] = "modified"
+	if !reflect.DeepEqual(m.State(), want) {
+		t.Fatal("state modified via copy")
+	}
+}
+
+func TestMaterialiserApplyInvalid(t *testing.T) {
+	ops := newTestOperators(3)
+	dag := newTestDAG(ops, 2)
+
+	m := NewMaterialiser()
+	if err := m.Apply(dag[1]); err == nil || !strings.Contains(err.Error(), "first mutation must be TypeCreateCluster") {
+		t.Fatalf("expected create cluster error, got %v", err)
+	} else if err := m.Apply(dag[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	before := m.State()
+
+	// The DKG transform succeeds before the acks fail, the state must not be partially updated.
+	partial := newTestComposite(dag[0].Hash, ops[0], TypeGenerateValidators, func(header Hash) any {
+		return GenerateValidators{
+			newTestMutation(header, ops[0], TypeDKG, newTestValidators(0, 2, ops)),
+			newTestAcks(header, ops[:2], newTestValidators(0, 2, ops)),
+		}
+	})
+
+	// A forged top-level hash, a child modified without rehashing the composite, and a forged child hash.
+	generate := newTestGenerateValidators(dag[0].Hash, ops, newTestValidators(0, 2, ops))
+	forgedHash := generate
+	forgedHash.Hash = Hash{1}
+
+	modifiedChild := generate
+	children := generate.Mutation.Data.(GenerateValidators)
+	children[0] = newTestMutation(children[0].Mutation.Parent, ops[0], TypeDKG, newTestValidators(1, 2, ops))
+	modifiedChild.Mutation.Data = children
+
+	forgedChild := generate
+	children = generate.Mutation.Data.(GenerateValidators)
+	children[0].Hash = Hash{1}
+	forgedChild.Mutation.Data = children
+	forgedChild = resealTest(forgedChild)
+
+	tests := []struct {
+		name     string
+		mutation SignedMutation
+		err      string
+	}{
+		{
+			name:     "forged hash",
+			mutation: forgedHash,
+			err:      "mutation 1: invalid hash of " + string(TypeGenerateValidators),
+		},
+		{
+			name:     "modified child",
+			mutation: modifiedChild,
+			err:      "mutation 1: invalid hash of " + string(TypeGenerateValidators),
+		},
+		{
+			name:     "forged child hash",
+			mutation: forgedChild,
+			err:      "mutation 1: child 0: invalid hash of " + string(TypeDKG),
+		},
+		{
+			name:     "create cluster again",
+			mutation: newTestCreateCluster(ops, 1),
+			err:      "mutation 1 is TypeCreateCluster",
+		},
+		{
+			name:     "not top level",
+			mutation: newTestApprovals(dag[0].Hash, ops),
+			err:      "not allowed",
+		},
+		{
+			name:     "parent mismatch",
+			mutation: newTestGenerateValidators(Hash{1}, ops, newTestValidators(0, 2, ops)),
+			err:      "invalid parent",
+		},
+		{
+			name:     "transform fails after partial update",
+			mutation: partial,
+			err:      "does not match number of operators",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := m.Apply(test.mutation); err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			} else if !reflect.DeepEqual(m.State(), before) || m.Head() != dag[0].Hash {
+				t.Fatal("state changed by failed apply")
+			}
+		})
+	}
+
+	if err := m.Apply(dag[1]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResumeMaterialiserInvalid(t *testing.T) {
+	for _, checkpoint := range []string{
+		`invalid`,
+		`{"Count": -1}`,
+		`{"Count": 1}`,
+		`{"Count": 0, "Head": [1,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0]}`,
+	} {
+		if _, err := ResumeMaterialiser([]byte(checkpoint)); err == nil {
+			t.Fatalf("expected error for checkpoint %s", checkpoint)
+		}
+	}
+}
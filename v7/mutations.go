@@ -11,45 +11,40 @@ const (
 	TypeOperatorENR        MutationType = "charon/operator_enr/1.0.0"
 	TypeGenerateValidators MutationType = "charon/generate_validators/1.0.0"
 	TypeDKG                MutationType = "charon/dkg/1.0.0"
+	TypeValidatorAcks      MutationType = "charon/validator_acks/1.0.0"
 	TypeValidatorAck       MutationType = "charon/validator_ack/1.0.0"
 	TypeAddValidators      MutationType = "charon/add_validators/1.0.0"
 	TypeProposeValidators  MutationType = "charon/propose_validators/1.0.0"
 	TypeOperatorApprovals  MutationType = "charon/operator_approvals/1.0.0"
 	TypeOperatorApproval   MutationType = "charon/operator_approval/1.0.0"
+	TypeReplaceOperators   MutationType = "charon/replace_operators/1.0.0"
+	TypeProposeOperators   MutationType = "charon/propose_operators/1.0.0"
+	TypeNewOperatorENRs    MutationType = "charon/new_operator_enrs/1.0.0"
+	TypeReshare            MutationType = "charon/reshare/1.0.0"
+	TypeReshareValidators  MutationType = "charon/reshare_validators/1.0.0"
+	TypeExitValidators     MutationType = "charon/exit_validators/1.0.0"
+	TypeProposeExit        MutationType = "charon/propose_exit/1.0.0"
+	TypeExitMessages       MutationType = "charon/exit_messages/1.0.0"
 )
 
-var typeDef = map[MutationType]struct {
-	DataType      any
-	VerifySigFunc func(ClusterState, SignedMutation) error
-	TransformFunc func(ClusterState, SignedMutation) (ClusterState, error)
-}{
-	TypeCreateCluster: {
-		DataType: CreateCluster{},
-		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
-			cc, ok := mutation.Mutation.Data.(CreateCluster)
-			if !ok {
-				return ClusterState{}, fmt.Errorf("mutation data is not CreateCluster")
-			}
-
-			if err := verifyLinearComposite(mutation, cc[:]); err != nil {
-				return ClusterState{}, fmt.Errorf("invalid linear chain: %w", err)
-			}
+// typeDef is populated in init via RegisterMutationType which validates the builtin definitions.
+var typeDef = make(map[MutationType]TypeDef)
 
-			if cc[0].Mutation.Type != TypeProposeCluster {
-				return ClusterState{}, fmt.Errorf("first mutation is not ProposeCluster")
-			} else if cc[1].Mutation.Type != TypeOperatorENRs {
-				return ClusterState{}, fmt.Errorf("second mutation is not OperatorENRs")
-			}
-
-			for _, m := range cc {
-				var err error
-				state, err = m.Mutation.Type.Transform(state, m)
-				if err != nil {
-					return ClusterState{}, fmt.Errorf("transform mutation: %w", err)
-				}
-			}
+func init() {
+	for typ, def := range builtinTypeDefs {
+		if err := RegisterMutationType(typ, def); err != nil {
+			panic(err)
+		}
+	}
+}
 
-			return state, nil
+var builtinTypeDefs = map[MutationType]TypeDef{
+	TypeCreateCluster: {
+		DataType: CreateCluster{},
+		TopLevel: true,
+		Composite: &CompositeDef{
+			Kind:     CompositeLinear,
+			Children: []MutationType{TypeProposeCluster, TypeOperatorENRs},
 		},
 	},
 	TypeProposeCluster: {
@@ -58,14 +53,19 @@ var typeDef = map[MutationType]struct {
 			// TODO(corver): validate signed mutation contains valid data
 			// TODO(corver): validate state doesn't contain existing cluster
 
-			state.Name = mutation.Mutation.Data.(ProposeCluster).Name
+			pc, ok := mutation.Mutation.Data.(ProposeCluster)
+			if !ok {
+				return ClusterState{}, fmt.Errorf("mutation data is not ProposeCluster")
+			}
+
+			state.Name = pc.Name
 
-			state.Operators = make([]Operator, len(mutation.Mutation.Data.(ProposeCluster).Operators))
+			state.Operators = make([]Operator, len(pc.Operators))
 			for i := 0; i < len(state.Operators); i++ {
-				state.Operators[i].PublicKey = mutation.Mutation.Data.(ProposeCluster).Operators[i]
+				state.Operators[i].PublicKey = pc.Operators[i]
 			}
 
-			state.Validators = make([]Validator, len(mutation.Mutation.Data.(ProposeCluster).Validators))
+			state.Validators = make([]Validator, len(pc.Validators))
 			// TODO(corver): Add validator feerecipient, withdrawal address, etc etc from to proposal to state.
 
 			return state, nil
@@ -73,25 +73,9 @@ var typeDef = map[MutationType]struct {
 	},
 	TypeOperatorENRs: {
 		DataType: OperatorENRs{},
-		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
-			enrs, ok := mutation.Mutation.Data.(OperatorENRs)
-			if !ok {
-				return ClusterState{}, fmt.Errorf("mutation data is not OperatorENRs")
-			}
-
-			if err := verifyAllParallel(mutation, enrs[:], TypeOperatorENR, state.Operators); err != nil {
-				return ClusterState{}, fmt.Errorf("invalid parralel composite: %w", err)
-			}
-
-			for _, m := range enrs {
-				var err error
-				state, err = m.Mutation.Type.Transform(state, m)
-				if err != nil {
-					return ClusterState{}, fmt.Errorf("transform mutation: %w", err)
-				}
-			}
-
-			return state, nil
+		Composite: &CompositeDef{
+			Kind:  CompositeParallel,
+			Child: TypeOperatorENR,
 		},
 	},
 	TypeOperatorENR: {
@@ -99,7 +83,10 @@ var typeDef = map[MutationType]struct {
 		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
 			// TODO(corver): Verify valid ENR.
 
-			enr := mutation.Mutation.Data.(OperatorENR).ENR
+			enr, ok := mutation.Mutation.Data.(OperatorENR)
+			if !ok {
+				return ClusterState{}, fmt.Errorf("mutation data is not OperatorENR")
+			}
 
 			for i := 0; i < len(state.Operators); i++ {
 				if state.Operators[i].PublicKey == mutation.Source {
@@ -107,7 +94,7 @@ var typeDef = map[MutationType]struct {
 						return state, fmt.Errorf("operator already has enr")
 					}
 
-					state.Operators[i].ENR = enr
+					state.Operators[i].ENR = enr.ENR
 
 					return state, nil
 				}
@@ -118,31 +105,10 @@ var typeDef = map[MutationType]struct {
 	},
 	TypeGenerateValidators: {
 		DataType: GenerateValidators{},
-		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
-			gv, ok := mutation.Mutation.Data.(GenerateValidators)
-			if !ok {
-				return ClusterState{}, fmt.Errorf("mutation data is not GenerateValidators")
-			}
-
-			if err := verifyLinearComposite(mutation, gv[:]); err != nil {
-				return ClusterState{}, fmt.Errorf("invalid linear composite: %w", err)
-			}
-
-			if gv[0].Mutation.Type != TypeDKG {
-				return ClusterState{}, fmt.Errorf("first mutation is not DKG")
-			} else if gv[1].Mutation.Type != TypeValidatorAck {
-				return ClusterState{}, fmt.Errorf("second mutation is not ValidatorAck")
-			}
-
-			for _, m := range gv {
-				var err error
-				state, err = m.Mutation.Type.Transform(state, m)
-				if err != nil {
-					return ClusterState{}, fmt.Errorf("transform mutation: %w", err)
-				}
-			}
-
-			return state, nil
+		TopLevel: true,
+		Composite: &CompositeDef{
+			Kind:     CompositeLinear,
+			Children: []MutationType{TypeDKG, TypeValidatorAcks},
 		},
 	},
 	TypeDKG: {
@@ -162,41 +128,53 @@ var typeDef = map[MutationType]struct {
 			return state, nil
 		},
 	},
-	TypeValidatorAck: {
-		DataType: nil,
-		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
-			return state, nil
+	TypeValidatorAcks: {
+		DataType: ValidatorAcks{},
+		Composite: &CompositeDef{
+			Kind:  CompositeParallel,
+			Child: TypeValidatorAck,
 		},
 	},
-	TypeAddValidators: {
-		DataType: AddValidators{},
+	TypeValidatorAck: {
+		DataType: ValidatorAck{},
 		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
-			av, ok := mutation.Mutation.Data.(AddValidators)
+			ack, ok := mutation.Mutation.Data.(ValidatorAck)
 			if !ok {
-				return ClusterState{}, fmt.Errorf("mutation data is not AddValidators")
+				return ClusterState{}, fmt.Errorf("mutation data is not ValidatorAck")
+			} else if len(ack.Shares) != len(state.Validators) {
+				return ClusterState{}, fmt.Errorf("number of share acks does not match number of validators")
 			}
 
-			if err := verifyLinearComposite(mutation, av[:]); err != nil {
-				return ClusterState{}, fmt.Errorf("invalid linear composite: %w", err)
+			idx := -1
+			for i, op := range state.Operators {
+				if op.PublicKey == mutation.Source {
+					idx = i
+				}
 			}
-
-			if av[0].Mutation.Type != TypeProposeValidators {
-				return ClusterState{}, fmt.Errorf("first mutation is not ProposeValidators")
-			} else if av[1].Mutation.Type != TypeOperatorApprovals {
-				return ClusterState{}, fmt.Errorf("second mutation is not OperatorApprovals")
+			if idx < 0 {
+				return ClusterState{}, fmt.Errorf("operator not found")
 			}
 
-			for _, m := range av {
-				var err error
-				state, err = m.Mutation.Type.Transform(state, m)
-				if err != nil {
-					return ClusterState{}, fmt.Errorf("transform mutation: %w", err)
+			for i, share := range ack.Shares {
+				val := state.Validators[i]
+				if share.ValidatorPublicKey != val.PublicKey {
+					return ClusterState{}, fmt.Errorf("share ack %d has invalid validator", i)
+				} else if idx >= len(val.PublicShares) || share.ShareCommitment != ShareCommitment(val.PublicKey, mutation.Source, val.PublicShares[idx]) {
+					return ClusterState{}, fmt.Errorf("share ack %d has invalid share commitment", i)
 				}
 			}
 
 			return state, nil
 		},
 	},
+	TypeAddValidators: {
+		DataType: AddValidators{},
+		TopLevel: true,
+		Composite: &CompositeDef{
+			Kind:     CompositeLinear,
+			Children: []MutationType{TypeProposeValidators, TypeOperatorApprovals},
+		},
+	},
 	TypeProposeValidators: {
 		DataType: Validators{},
 		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
@@ -211,62 +189,199 @@ var typeDef = map[MutationType]struct {
 	},
 	TypeOperatorApprovals: {
 		DataType: OperatorApprovals{},
+		Composite: &CompositeDef{
+			Kind:  CompositeParallel,
+			Child: TypeOperatorApproval,
+		},
+	},
+	TypeOperatorApproval: {
+		DataType: nil,
+		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
+			return state, nil
+		},
+	},
+	TypeReplaceOperators: {
+		DataType: ReplaceOperators{},
+		TopLevel: true,
+		Composite: &CompositeDef{
+			Kind:     CompositeLinear,
+			Children: []MutationType{TypeOperatorApprovals, TypeProposeOperators, TypeNewOperatorENRs, TypeReshare, TypeValidatorAcks},
+		},
+	},
+	TypeProposeOperators: {
+		DataType: ProposeOperators{},
 		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
-			approvals, ok := mutation.Mutation.Data.(OperatorApprovals)
+			po, ok := mutation.Mutation.Data.(ProposeOperators)
 			if !ok {
-				return ClusterState{}, fmt.Errorf("mutation data is not OperatorApprovals")
+				return ClusterState{}, fmt.Errorf("mutation data is not ProposeOperators")
+			} else if len(state.Operators) == 0 {
+				return ClusterState{}, fmt.Errorf("cluster has no operators")
+			} else if len(po.Operators) == 0 {
+				return ClusterState{}, fmt.Errorf("no operators proposed")
 			}
 
-			if err := verifyAllParallel(mutation, approvals[:], TypeOperatorApproval, state.Operators); err != nil {
-				return ClusterState{}, fmt.Errorf("invalid parralel composite: %w", err)
+			// Retained operators keep their ENRs, new operators provide theirs via TypeNewOperatorENRs.
+			ops := make([]Operator, 0, len(po.Operators))
+			for _, key := range po.Operators {
+				if isOperator(ops, key) {
+					return ClusterState{}, fmt.Errorf("duplicate operator: %s", key)
+				}
+
+				op := Operator{PublicKey: key}
+				for _, prev := range state.Operators {
+					if prev.PublicKey == key {
+						op = prev
+					}
+				}
+				ops = append(ops, op)
+			}
+			state.Operators = ops
+
+			return state, nil
+		},
+	},
+	TypeNewOperatorENRs: {
+		DataType: NewOperatorENRs{},
+		Composite: &CompositeDef{
+			Kind:  CompositeParallel,
+			Child: TypeOperatorENR,
+			Operators: func(state ClusterState) []Operator {
+				var resp []Operator
+				for _, op := range state.Operators {
+					if op.ENR == "" {
+						resp = append(resp, op)
+					}
+				}
+
+				return resp
+			},
+		},
+	},
+	TypeReshare: {
+		DataType: Validators{},
+		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
+			vals, ok := mutation.Mutation.Data.(Validators)
+			if !ok {
+				return ClusterState{}, fmt.Errorf("mutation data is not Validators")
+			} else if len(vals) != len(state.Validators) {
+				return ClusterState{}, fmt.Errorf("number of validators does not match reshare")
 			}
 
-			for _, m := range approvals {
-				var err error
-				state, err = m.Mutation.Type.Transform(state, m)
-				if err != nil {
-					return ClusterState{}, fmt.Errorf("transform mutation: %w", err)
+			for i, val := range vals {
+				if val.PublicKey != state.Validators[i].PublicKey {
+					return ClusterState{}, fmt.Errorf("reshare changes validator %d public key", i)
+				} else if len(val.PublicShares) != len(state.Operators) {
+					return ClusterState{}, fmt.Errorf("validator %d shares do not match operators", i)
 				}
 			}
 
+			state.Validators = vals
+
 			return state, nil
 		},
 	},
-	TypeOperatorApproval: {
-		DataType: nil,
+	TypeReshareValidators: {
+		DataType: ReshareValidators{},
+		TopLevel: true,
+		Composite: &CompositeDef{
+			Kind:     CompositeLinear,
+			Children: []MutationType{TypeReshare, TypeOperatorApprovals},
+		},
+	},
+	TypeExitValidators: {
+		DataType: ExitValidators{},
+		TopLevel: true,
+		Composite: &CompositeDef{
+			Kind:     CompositeLinear,
+			Children: []MutationType{TypeProposeExit, TypeOperatorApprovals, TypeExitMessages},
+			Optional: 1,
+		},
+	},
+	TypeProposeExit: {
+		DataType: ProposeExit{},
 		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
+			pe, ok := mutation.Mutation.Data.(ProposeExit)
+			if !ok {
+				return ClusterState{}, fmt.Errorf("mutation data is not ProposeExit")
+			} else if len(pe.Validators) == 0 {
+				return ClusterState{}, fmt.Errorf("no validators proposed")
+			}
+
+			exits := make(map[PublicKey]bool)
+			for _, key := range pe.Validators {
+				if key == "" {
+					return ClusterState{}, fmt.Errorf("empty validator public key")
+				} else if containsKey(state.ExitedValidators, key) {
+					return ClusterState{}, fmt.Errorf("validator already exited: %s", key)
+				} else if exits[key] {
+					return ClusterState{}, fmt.Errorf("duplicate validator: %s", key)
+				}
+				exits[key] = true
+			}
+
+			var vals []Validator
+			for _, val := range state.Validators {
+				if exits[val.PublicKey] {
+					delete(exits, val.PublicKey)
+					continue
+				}
+				vals = append(vals, val)
+			}
+			if len(exits) > 0 {
+				return ClusterState{}, fmt.Errorf("unknown validator proposed")
+			}
+
+			state.Validators = vals
+			state.ExitedValidators = append(append([]PublicKey(nil), state.ExitedValidators...), pe.Validators...)
+
 			return state, nil
 		},
 	},
-}
+	TypeExitMessages: {
+		DataType: ExitMessages{},
+		TransformFunc: func(state ClusterState, mutation SignedMutation) (ClusterState, error) {
+			// TODO(corver): Verify exit message signatures.
 
-func verifyAllParallel(parent SignedMutation, mutations []SignedMutation, expectedType MutationType, operators []Operator) error {
-	if len(mutations) != len(operators) {
-		return fmt.Errorf("number of parralel mutations do not match number of operators")
-	}
+			msgs, ok := mutation.Mutation.Data.(ExitMessages)
+			if !ok {
+				return ClusterState{}, fmt.Errorf("mutation data is not ExitMessages")
+			}
 
-	for i, m := range mutations {
-		if m.Source != operators[i].PublicKey {
-			return fmt.Errorf("mutation %d has invalid source", i)
-		}
+			done := make(map[PublicKey]bool)
+			for _, msg := range msgs {
+				if !containsKey(state.ExitedValidators, msg.ValidatorPublicKey) {
+					return ClusterState{}, fmt.Errorf("exit message for validator not exited: %s", msg.ValidatorPublicKey)
+				} else if done[msg.ValidatorPublicKey] {
+					return ClusterState{}, fmt.Errorf("duplicate exit message: %s", msg.ValidatorPublicKey)
+				} else if len(msg.Signature) == 0 {
+					return ClusterState{}, fmt.Errorf("exit message missing signature: %s", msg.ValidatorPublicKey)
+				}
+				done[msg.ValidatorPublicKey] = true
+			}
 
-		if m.Mutation.Type != expectedType {
-			return fmt.Errorf("mutation %d has invalid type", i)
-		}
-		if m.Mutation.Parent != parent.Hash {
-			return fmt.Errorf("mutation %d has invalid parent", i)
+			return state, nil
+		},
+	},
+}
+
+// containsKey returns true if the keys contain the key.
+func containsKey(keys []PublicKey, key PublicKey) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
 		}
 	}
 
-	return nil
+	return false
 }
 
-func verifyLinearComposite(parent SignedMutation, mutations []SignedMutation) error {
-	for i, m := range mutations {
-		if m.Mutation.Parent != parent.Hash {
-			return fmt.Errorf("mutation %d has invalid parent", i)
+// isOperator returns true if the key is one of the operators.
+func isOperator(operators []Operator, key PublicKey) bool {
+	for _, op := range operators {
+		if op.PublicKey == key {
+			return true
 		}
 	}
 
-	return nil
+	return false
 }
@@ -0,0 +1,296 @@
+package v5
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReplaceOperators(t *testing.T) {
+	ops := newTestOperators(4)
+	newOps := append(ops[:3:3], "operator-4")
+	dag := newTestDAG(ops, 2)
+
+	t.Run("valid", func(t *testing.T) {
+		vals := newTestValidators(0, 2, newOps)
+		state, err := MaterialiseDV(append(dag, newTestReplaceOperators(dag[1].Hash, ops, newOps, vals)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, op := range state.Operators {
+			if op.PublicKey != newOps[i] || op.ENR != "enr://"+string(newOps[i]) {
+				t.Fatalf("unexpected operator %d: %+v", i, op)
+			}
+		}
+		if !reflect.DeepEqual(state.Validators, []Validator(vals)) {
+			t.Fatalf("unexpected validators: %+v", state.Validators)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		modify func(data *ReplaceOperators, parent Hash)
+		err    string
+	}{
+		{
+			name: "approved by new operators",
+			modify: func(data *ReplaceOperators, parent Hash) {
+				data[0] = newTestApprovals(parent, newOps)
+			},
+			err: "invalid source",
+		},
+		{
+			name: "approved by some current operators",
+			modify: func(data *ReplaceOperators, parent Hash) {
+				data[0] = newTestApprovals(parent, ops[:3])
+			},
+			err: "does not match number of operators",
+		},
+		{
+			name: "acknowledged by previous operators",
+			modify: func(data *ReplaceOperators, parent Hash) {
+				data[4] = newTestAcks(parent, ops, newTestValidators(0, 2, newOps))
+			},
+			err: "invalid source",
+		},
+		{
+			name: "approvals replayed as acks",
+			modify: func(data *ReplaceOperators, parent Hash) {
+				data[4] = data[0]
+			},
+			err: "mutation 4 is not " + string(TypeValidatorAcks),
+		},
+		{
+			name: "acks of other shares",
+			modify: func(data *ReplaceOperators, parent Hash) {
+				data[4] = newTestAcks(parent, newOps, newTestValidators(0, 2, ops))
+			},
+			err: "invalid share commitment",
+		},
+		{
+			name: "missing new operator enr",
+			modify: func(data *ReplaceOperators, parent Hash) {
+				data[2].Mutation.Data = NewOperatorENRs{}
+				data[2] = resealTest(data[2])
+			},
+			err: "does not match number of operators",
+		},
+		{
+			name: "reshare to previous operators",
+			modify: func(data *ReplaceOperators, parent Hash) {
+				data[3] = newTestMutation(parent, ops[0], TypeReshare, newTestValidators(0, 2, ops[:2]))
+			},
+			err: "shares do not match operators",
+		},
+		{
+			name: "reshare changes validators",
+			modify: func(data *ReplaceOperators, parent Hash) {
+				data[3] = newTestMutation(parent, ops[0], TypeReshare, newTestValidators(1, 2, newOps))
+			},
+			err: "changes validator 0 public key",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			replace := newTestReplaceOperators(dag[1].Hash, ops, newOps, newTestValidators(0, 2, newOps))
+			data := replace.Mutation.Data.(ReplaceOperators)
+			test.modify(&data, replace.Mutation.HeaderHash(replace.Source))
+			replace.Mutation.Data = data
+			replace = resealTest(replace)
+
+			_, err := MaterialiseDV(append(dag, replace))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestReshareValidators(t *testing.T) {
+	ops := newTestOperators(3)
+	dag := newTestDAG(ops, 1)
+	vals := Validators{{PublicKey: "validator-0", PublicShares: []PublicKey{"a", "b", "c"}}}
+
+	reshare := newTestComposite(dag[1].Hash, ops[0], TypeReshareValidators, func(header Hash) any {
+		return ReshareValidators{
+			newTestMutation(header, ops[0], TypeReshare, vals),
+			newTestApprovals(header, ops),
+		}
+	})
+
+	state, err := MaterialiseDV(append(dag, reshare))
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(state.Validators, []Validator(vals)) {
+		t.Fatalf("unexpected validators: %+v", state.Validators)
+	}
+}
+
+func TestExitValidators(t *testing.T) {
+	ops := newTestOperators(3)
+	dag := newTestDAG(ops, 3)
+	msg := func(val PublicKey) ExitMessage {
+		return ExitMessage{ValidatorPublicKey: val, Epoch: 1, Signature: []byte("sig")}
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		exit1 := newTestExitValidators(dag[1].Hash, ops, []PublicKey{"validator-1"}, ExitMessages{msg("validator-1")})
+		exit2 := newTestExitValidators(exit1.Hash, ops, []PublicKey{"validator-0"}, nil) // Exit messages are optional.
+
+		state, err := MaterialiseDV(append(dag, exit1, exit2))
+		if err != nil {
+			t.Fatal(err)
+		} else if len(state.Validators) != 1 || state.Validators[0].PublicKey != "validator-2" {
+			t.Fatalf("unexpected validators: %+v", state.Validators)
+		} else if !reflect.DeepEqual(state.ExitedValidators, []PublicKey{"validator-1", "validator-0"}) {
+			t.Fatalf("unexpected exited validators: %v", state.ExitedValidators)
+		}
+	})
+
+	tests := []struct {
+		name string
+		vals []PublicKey
+		msgs ExitMessages
+		err  string
+	}{
+		{name: "no validators", err: "no validators proposed"},
+		{name: "unknown validator", vals: []PublicKey{"validator-9"}, err: "unknown validator"},
+		{name: "duplicate validator", vals: []PublicKey{"validator-0", "validator-0"}, err: "duplicate validator"},
+		{name: "message for validator not exited", vals: []PublicKey{"validator-0"}, msgs: ExitMessages{msg("validator-1")}, err: "not exited"},
+		{name: "duplicate message", vals: []PublicKey{"validator-0"}, msgs: ExitMessages{msg("validator-0"), msg("validator-0")}, err: "duplicate exit message"},
+		{name: "message missing signature", vals: []PublicKey{"validator-0"}, msgs: ExitMessages{{ValidatorPublicKey: "validator-0"}}, err: "missing signature"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := MaterialiseDV(append(dag, newTestExitValidators(dag[1].Hash, ops, test.vals, test.msgs)))
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+
+	t.Run("exited again", func(t *testing.T) {
+		exit1 := newTestExitValidators(dag[1].Hash, ops, []PublicKey{"validator-0"}, nil)
+		exit2 := newTestExitValidators(exit1.Hash, ops, []PublicKey{"validator-0"}, nil)
+
+		if _, err := MaterialiseDV(append(dag, exit1, exit2)); err == nil || !strings.Contains(err.Error(), "already exited") {
+			t.Fatalf("expected already exited error, got %v", err)
+		}
+	})
+
+	t.Run("exited validator added again", func(t *testing.T) {
+		exit := newTestExitValidators(dag[1].Hash, ops, []PublicKey{"validator-0"}, nil)
+		add := newTestComposite(exit.Hash, ops[0], TypeAddValidators, func(header Hash) any {
+			return AddValidators{
+				newTestMutation(header, ops[0], TypeProposeValidators, newTestValidators(0, 1, ops)),
+				newTestApprovals(header, ops),
+			}
+		})
+
+		if _, err := MaterialiseDV(append(dag, exit, add)); err == nil || !strings.Contains(err.Error(), "exited validator referenced") {
+			t.Fatalf("expected exited validator error, got %v", err)
+		}
+	})
+}
+
+func TestShareCommitment(t *testing.T) {
+	c := ShareCommitment("validator", "operator", "share")
+	if c != ShareCommitment("validator", "operator", "share") {
+		t.Fatal("expected deterministic commitment")
+	}
+
+	for _, other := range []Hash{
+		ShareCommitment("other", "operator", "share"),
+		ShareCommitment("validator", "other", "share"),
+		ShareCommitment("validator", "operator", "other"),
+		ShareCommitment("validatoro", "perator", "share"), // Same concatenation.
+	} {
+		if other == c {
+			t.Fatal("expected distinct commitments")
+		}
+	}
+}
+
+func TestValidatorAcks(t *testing.T) {
+	ops := newTestOperators(3)
+	create := newTestCreateCluster(ops, 2)
+	vals := newTestValidators(0, 2, ops)
+
+	newGenerate := func(modify func(acks ValidatorAcks, parent Hash) ValidatorAcks) SignedMutation {
+		generate := newTestGenerateValidators(create.Hash, ops, vals)
+		data := generate.Mutation.Data.(GenerateValidators)
+		acks := data[1]
+		acks.Mutation.Data = modify(acks.Mutation.Data.(ValidatorAcks), acks.Mutation.HeaderHash(acks.Source))
+		data[1] = resealTest(acks)
+		generate.Mutation.Data = data
+
+		return resealTest(generate)
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		generate := newGenerate(func(acks ValidatorAcks, _ Hash) ValidatorAcks { return acks })
+		if _, err := MaterialiseDV(RawDAG{create, generate}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	tests := []struct {
+		name   string
+		modify func(acks ValidatorAcks, parent Hash) ValidatorAcks
+		err    string
+	}{
+		{
+			name: "missing operator",
+			modify: func(acks ValidatorAcks, _ Hash) ValidatorAcks {
+				return acks[:2]
+			},
+			err: "does not match number of operators",
+		},
+		{
+			name: "replayed from another operator",
+			modify: func(acks ValidatorAcks, parent Hash) ValidatorAcks {
+				acks[1] = newTestMutation(parent, ops[1], TypeValidatorAck, acks[0].Mutation.Data)
+				return acks
+			},
+			err: "share ack 0 has invalid share commitment",
+		},
+		{
+			name: "missing validator",
+			modify: func(acks ValidatorAcks, parent Hash) ValidatorAcks {
+				ack := acks[0].Mutation.Data.(ValidatorAck)
+				acks[0] = newTestMutation(parent, ops[0], TypeValidatorAck, ValidatorAck{Shares: ack.Shares[:1]})
+				return acks
+			},
+			err: "number of share acks does not match",
+		},
+		{
+			name: "validator order",
+			modify: func(acks ValidatorAcks, parent Hash) ValidatorAcks {
+				ack := acks[0].Mutation.Data.(ValidatorAck)
+				shares := []ShareAck{ack.Shares[1], ack.Shares[0]}
+				acks[0] = newTestMutation(parent, ops[0], TypeValidatorAck, ValidatorAck{Shares: shares})
+				return acks
+			},
+			err: "share ack 0 has invalid validator",
+		},
+		{
+			name: "commitment of another validator",
+			modify: func(acks ValidatorAcks, parent Hash) ValidatorAcks {
+				ack := acks[0].Mutation.Data.(ValidatorAck)
+				shares := []ShareAck{ack.Shares[0], {ValidatorPublicKey: vals[1].PublicKey, ShareCommitment: ack.Shares[0].ShareCommitment}}
+				acks[0] = newTestMutation(parent, ops[0], TypeValidatorAck, ValidatorAck{Shares: shares})
+				return acks
+			},
+			err: "share ack 1 has invalid share commitment",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := MaterialiseDV(RawDAG{create, newGenerate(test.modify)})
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
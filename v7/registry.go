@@ -0,0 +1,95 @@
+package v5
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/corverroos/clusterstate"
+)
+
+// TypeDef defines a mutation type.
+type TypeDef struct {
+	// DataType is the zero value of the mutation data.
+	DataType any
+	// TopLevel is true if the type may be included directly in the RawDAG, as opposed to only inside composites.
+	TopLevel bool
+	// VerifySigFunc optionally verifies the mutation signature(s).
+	VerifySigFunc func(ClusterState, SignedMutation) error
+	// TransformFunc transforms the cluster state by applying the mutation, it must be nil for composites.
+	TransformFunc func(ClusterState, SignedMutation) (ClusterState, error)
+	// Composite optionally defines the type as a composite, transformed by the framework.
+	Composite *CompositeDef
+}
+
+// RegisterMutationType registers a custom mutation type.
+// It returns an error if the type conflicts with a registered version of the same name.
+// It is not safe for concurrent use and should be called during program initialisation.
+func RegisterMutationType(typ MutationType, def TypeDef) error {
+	if err := verifyTypeName(typ); err != nil {
+		return err
+	} else if _, ok := typeDef[typ]; ok {
+		return fmt.Errorf("mutation type already registered: %s", typ)
+	} else if def.Composite == nil && def.TransformFunc == nil {
+		return fmt.Errorf("mutation type missing transform func: %s", typ)
+	} else if def.Composite != nil && def.TransformFunc != nil {
+		return fmt.Errorf("composite mutation type has transform func: %s", typ)
+	} else if def.Composite != nil {
+		if err := def.Composite.verify(def.DataType); err != nil {
+			return fmt.Errorf("invalid composite mutation type: %s: %w", typ, err)
+		}
+	}
+
+	if err := verifyVersionConflicts(typ, def); err != nil {
+		return err
+	}
+
+	typeDef[typ] = def
+
+	return nil
+}
+
+// verifyTypeName returns an error if the type isn't of the form "namespace/name/major.minor.patch".
+func verifyTypeName(typ MutationType) error {
+	_, _, err := parseType(typ)
+	return err
+}
+
+// verifyVersionConflicts returns an error if the type has the same name and major.minor version
+// as a registered type but a different data type, since patch versions must be data compatible.
+func verifyVersionConflicts(typ MutationType, def TypeDef) error {
+	name, version, err := parseType(typ)
+	if err != nil {
+		return err
+	}
+
+	for other, otherDef := range typeDef {
+		otherName, otherVersion, err := parseType(other)
+		if err != nil {
+			return err
+		}
+
+		if otherName == name && otherVersion.Major == version.Major && otherVersion.Minor == version.Minor &&
+			reflect.TypeOf(otherDef.DataType) != reflect.TypeOf(def.DataType) {
+			return fmt.Errorf("mutation type data type conflicts with %s: %s", other, typ)
+		}
+	}
+
+	return nil
+}
+
+// parseType splits the type into its "namespace/name" and semantic version,
+// e.g. "charon/dkg/1.0.0" into "charon/dkg" and 1.0.0.
+func parseType(typ MutationType) (string, clusterstate.Version, error) {
+	parts := strings.Split(string(typ), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+		return "", clusterstate.Version{}, fmt.Errorf("invalid mutation type: %s", typ)
+	}
+
+	version, err := clusterstate.ParseVersion(parts[2])
+	if err != nil {
+		return "", clusterstate.Version{}, fmt.Errorf("invalid mutation type: %s: %w", typ, err)
+	}
+
+	return parts[0] + "/" + parts[1], version, nil
+}
@@ -0,0 +1,121 @@
+package v5
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterMutationType(t *testing.T) {
+	type dataV1 struct{ A int }
+	type dataV2 struct{ A, B int }
+
+	transform := func(state ClusterState, _ SignedMutation) (ClusterState, error) {
+		return state, nil
+	}
+
+	register := func(t *testing.T, typ MutationType, def TypeDef) error {
+		t.Helper()
+		err := RegisterMutationType(typ, def)
+		if err == nil {
+			t.Cleanup(func() { delete(typeDef, typ) })
+		}
+
+		return err
+	}
+
+	tests := []struct {
+		name     string
+		existing map[MutationType]TypeDef
+		typ      MutationType
+		def      TypeDef
+		err      string
+	}{
+		{
+			name: "valid",
+			typ:  "test/metadata/1.0.0",
+			def:  TypeDef{DataType: dataV1{}, TransformFunc: transform},
+		},
+		{
+			name: "invalid name",
+			typ:  "test/1.0.0",
+			def:  TypeDef{TransformFunc: transform},
+			err:  "invalid mutation type",
+		},
+		{
+			name: "invalid version",
+			typ:  "test/metadata/1.0",
+			def:  TypeDef{TransformFunc: transform},
+			err:  "invalid version",
+		},
+		{
+			name: "non numeric version",
+			typ:  "test/metadata/1.x.0",
+			def:  TypeDef{TransformFunc: transform},
+			err:  "invalid version",
+		},
+		{
+			name: "leading zero version",
+			typ:  "test/metadata/1.01.0",
+			def:  TypeDef{TransformFunc: transform},
+			err:  "invalid version",
+		},
+		{
+			name: "builtin duplicate",
+			typ:  TypeDKG,
+			def:  TypeDef{DataType: Validators{}, TransformFunc: transform},
+			err:  "already registered",
+		},
+		{
+			name: "missing transform func",
+			typ:  "test/metadata/1.0.0",
+			err:  "missing transform func",
+		},
+		{
+			name: "composite with transform func",
+			typ:  "test/composite/1.0.0",
+			def:  TypeDef{DataType: OperatorApprovals{}, TransformFunc: transform, Composite: &CompositeDef{Kind: CompositeParallel, Child: TypeOperatorApproval}},
+			err:  "composite mutation type has transform func",
+		},
+		{
+			name: "parallel composite with array data",
+			typ:  "test/composite/1.0.0",
+			def:  TypeDef{DataType: CreateCluster{}, Composite: &CompositeDef{Kind: CompositeParallel, Child: TypeOperatorApproval}},
+			err:  "must be a slice",
+		},
+		{
+			name: "linear composite length mismatch",
+			typ:  "test/composite/1.0.0",
+			def:  TypeDef{DataType: CreateCluster{}, Composite: &CompositeDef{Kind: CompositeLinear, Children: []MutationType{TypeDKG}}},
+			err:  "length does not match",
+		},
+		{
+			name:     "patch with different data type",
+			existing: map[MutationType]TypeDef{"test/metadata/1.0.0": {DataType: dataV1{}, TransformFunc: transform}},
+			typ:      "test/metadata/1.0.1",
+			def:      TypeDef{DataType: dataV2{}, TransformFunc: transform},
+			err:      "data type conflicts",
+		},
+		{
+			name:     "minor with different data type",
+			existing: map[MutationType]TypeDef{"test/metadata/1.0.0": {DataType: dataV1{}, TransformFunc: transform}},
+			typ:      "test/metadata/1.1.0",
+			def:      TypeDef{DataType: dataV2{}, TransformFunc: transform},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for typ, def := range test.existing {
+				if err := register(t, typ, def); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := register(t, test.typ, test.def)
+			if test.err == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+				t.Fatalf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -4,9 +4,33 @@ type ClusterState struct {
 	Name       string
 	Operators  []Operator
 	Validators []Validator
+	// ExitedValidators are the public keys of exited validators which may not be referenced again.
+	ExitedValidators []PublicKey
 }
 
 type Operator struct {
 	PublicKey PublicKey
 	ENR       string
 }
+
+// Clone returns a deep copy of the cluster state.
+func (s ClusterState) Clone() ClusterState {
+	resp := s
+	if s.Operators != nil {
+		resp.Operators = append([]Operator{}, s.Operators...)
+	}
+	if s.Validators != nil {
+		resp.Validators = make([]Validator, 0, len(s.Validators))
+		for _, v := range s.Validators {
+			if v.PublicShares != nil {
+				v.PublicShares = append([]PublicKey{}, v.PublicShares...)
+			}
+			resp.Validators = append(resp.Validators, v)
+		}
+	}
+	if s.ExitedValidators != nil {
+		resp.ExitedValidators = append([]PublicKey{}, s.ExitedValidators...)
+	}
+
+	return resp
+}
@@ -3,6 +3,8 @@ package v5
 import (
 	"crypto/sha256"
 	"encoding/json"
+	"fmt"
+	"reflect"
 )
 
 // DutyType represents the type of a validator duty; attester, proposer, etc.
@@ -18,12 +20,32 @@ type Hash [32]byte
 type MutationType string
 
 func (t MutationType) Transform(cl ClusterState, signedMutation SignedMutation) (ClusterState, error) {
-	err := typeDef[t].VerifySigFunc(cl, signedMutation)
-	if err != nil {
-		return ClusterState{}, err
+	def, ok := typeDef[t]
+	if !ok {
+		return ClusterState{}, fmt.Errorf("unknown mutation type: %s", t)
+	}
+
+	if def.VerifySigFunc != nil {
+		if err := def.VerifySigFunc(cl, signedMutation); err != nil {
+			return ClusterState{}, err
+		}
 	}
 
-	return typeDef[t].TransformFunc(cl, signedMutation)
+	if def.Composite != nil {
+		return transformComposite(def, cl, signedMutation)
+	}
+
+	return def.TransformFunc(cl, signedMutation)
+}
+
+// TopLevel returns true if the mutation type may be included directly in the RawDAG.
+func (t MutationType) TopLevel() bool {
+	return typeDef[t].TopLevel
+}
+
+// Composite returns true if the mutation type is a composite of child mutations.
+func (t MutationType) Composite() bool {
+	return typeDef[t].Composite != nil
 }
 
 // Validator represents a validator in the cluster.
@@ -56,6 +78,59 @@ func (m Mutation) Hash() Hash {
 	return Hash(sha256.Sum256(b))
 }
 
+// SignedHash returns the hash of the mutation created by the source.
+// The source is included since operators create identical composite children, e.g. approvals.
+// Composite hashes commit to the header hash and the ordered hashes of the children, since the
+// children refer to the header hash as their parent.
+func (m Mutation) SignedHash(source PublicKey) Hash {
+	if !m.Type.Composite() {
+		return signedHash(m, source)
+	}
+
+	resp := struct {
+		Header   Hash
+		Children []Hash
+	}{Header: m.HeaderHash(source)}
+
+	// Data that isn't an array or slice of children is rejected when the composite is transformed.
+	val := reflect.ValueOf(m.Data)
+	if m.Data != nil && (val.Kind() == reflect.Array || val.Kind() == reflect.Slice) &&
+		val.Type().Elem() == reflect.TypeOf(SignedMutation{}) {
+		for i := 0; i < val.Len(); i++ {
+			child := val.Index(i).Interface().(SignedMutation)
+			resp.Children = append(resp.Children, child.Mutation.SignedHash(child.Source))
+		}
+	}
+
+	b, err := json.Marshal(resp)
+	if err != nil {
+		panic(err)
+	}
+
+	return Hash(sha256.Sum256(b))
+}
+
+// HeaderHash returns the hash of the mutation created by the source, excluding its data.
+// Composite children refer to the header hash of the composite as their parent.
+func (m Mutation) HeaderHash(source PublicKey) Hash {
+	m.Data = nil
+
+	return signedHash(m, source)
+}
+
+// signedHash returns the hash of the JSON encoding of the mutation and its source.
+func signedHash(m Mutation, source PublicKey) Hash {
+	b, err := json.Marshal(struct {
+		Mutation Mutation
+		Source   PublicKey
+	}{m, source})
+	if err != nil {
+		panic(err)
+	}
+
+	return Hash(sha256.Sum256(b))
+}
+
 type ProposeCluster struct {
 	Name       string
 	Operators  []PublicKey
@@ -79,3 +154,61 @@ type Validators []Validator
 type AddValidators [2]SignedMutation
 
 type OperatorApprovals []SignedMutation
+
+type ProposeOperators struct {
+	Operators []PublicKey
+}
+
+// ReplaceOperators is approved by the current operators before the proposal is applied, and acknowledged
+// by the new operators after resharing via validator acks committing to their reshared shares, so that
+// approvals of the current operators cannot be replayed as acknowledgements.
+type ReplaceOperators [5]SignedMutation
+
+type NewOperatorENRs []SignedMutation
+
+type ReshareValidators [2]SignedMutation
+
+type ExitValidators []SignedMutation
+
+type ProposeExit struct {
+	Validators []PublicKey
+}
+
+type ExitMessage struct {
+	ValidatorPublicKey PublicKey
+	Epoch              uint64
+	Signature          []byte
+}
+
+type ExitMessages []ExitMessage
+
+type ValidatorAcks []SignedMutation
+
+// ValidatorAck is an operator's acknowledgement that it holds its share of each validator, in validator order.
+type ValidatorAck struct {
+	Shares []ShareAck
+}
+
+type ShareAck struct {
+	ValidatorPublicKey PublicKey
+	ShareCommitment    Hash
+}
+
+// ShareCommitment returns the commitment to the operator's public share of the validator included in validator acks.
+// It binds the share to the validator and operator so an ack can't be replayed for another operator or validator.
+//
+// Note that the commitment is computed from public data only, so it proves the operator received the DKG output,
+// not that it holds the private share. Proving possession requires the ack to be signed by the share, e.g. a BLS
+// signature of the validator public key verified against the public share, which this model does not include.
+func ShareCommitment(validator, operator, share PublicKey) Hash {
+	h := sha256.New()
+	for _, key := range []PublicKey{validator, operator, share} {
+		// Length prefix each key to avoid ambiguous concatenations.
+		_, _ = fmt.Fprintf(h, "%d:%s", len(key), key)
+	}
+
+	var resp Hash
+	copy(resp[:], h.Sum(nil))
+
+	return resp
+}
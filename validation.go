@@ -3,25 +3,45 @@ package clusterstate
 import (
 	"fmt"
 	"sort"
+	"time"
 )
 
+// MaxClockDrift is the maximum duration a mutation timestamp may be ahead of the local clock.
+const MaxClockDrift = time.Minute
+
 // ValidateAdd validates that a mutation can be added to the state.
 // It assumes that fork choice has already been applied to state.
 func ValidateAdd(state State, sm SignedMutation) error {
+	def, ok := typeDef[sm.Mutation.Type]
+	if !ok {
+		return fmt.Errorf("unknown mutation type: %s", sm.Mutation.Type)
+	} else if sm.Hash != sm.Mutation.Hash(sm.Source) {
+		return fmt.Errorf("invalid mutation hash")
+	}
+
 	if len(state) == 0 && sm.Mutation.Type != TypeCreateCluster {
 		return fmt.Errorf("first mutation must be create cluster")
-	} else if sm.Mutation.Type.Approvals() == ApprovalsNone && len(sm.Mutation.ParentHashes) > 1 {
+	} else if len(state) == 0 {
+		return nil
+	} else if len(sm.Mutation.ParentHashes) == 0 && sm.Mutation.Type != TypeCreateCluster {
+		return fmt.Errorf("mutation without parents must be create cluster")
+	} else if isNoApprovals(sm.Mutation.Type.Approvals()) && len(sm.Mutation.ParentHashes) > 1 {
 		return fmt.Errorf("approval mutation may only depend on a single parent")
 	}
 
-	allowedParents := sm.Mutation.Type.ParentTypes()
-
 	clusters, err := Resolve(state)
 	if err != nil {
 		return err
+	} else if len(clusters) == 0 {
+		return fmt.Errorf("no cluster heads")
 	}
 	sort.Slice(clusters, func(i, j int) bool {
-		return clusters[i].ApprovedMutations > clusters[j].ApprovedMutations
+		if clusters[i].ApprovedMutations != clusters[j].ApprovedMutations {
+			return clusters[i].ApprovedMutations > clusters[j].ApprovedMutations
+		}
+
+		// Prefer the fork containing the parents, since approvals result in multiple equally long forks.
+		return containsAll(clusters[i], sm.Mutation.ParentHashes) && !containsAll(clusters[j], sm.Mutation.ParentHashes)
 	})
 
 	operators := make(map[PublicKey]bool)
@@ -35,15 +55,19 @@ func ValidateAdd(state State, sm SignedMutation) error {
 			return err
 		}
 
-		if !allowedParents[parent.Mutation.Type] {
+		if sm.Mutation.Timestamp.Before(parent.Mutation.Timestamp) {
+			return fmt.Errorf("mutation timestamp before parent")
+		}
+
+		if !sm.Mutation.Type.AllowsParent(parent.Mutation.Type) {
 			return fmt.Errorf("parent mutation type is not allowed")
 		}
 
-		if parent.Mutation.Type == sm.Mutation.Type && parent.Source == sm.Source {
+		if parent.Mutation.Type.Name() == sm.Mutation.Type.Name() && parent.Source == sm.Source {
 			return fmt.Errorf("duplicate parent mutation")
 		}
 
-		if sm.Mutation.Type.Approvals() == ApprovalsNone {
+		if isNoApprovals(sm.Mutation.Type.Approvals()) {
 			continue
 		}
 
@@ -57,5 +81,30 @@ func ValidateAdd(state State, sm SignedMutation) error {
 		operators[parent.Source] = true
 	}
 
+	if def.ValidateFunc != nil {
+		return def.ValidateFunc(state, sm)
+	}
+
 	return nil
 }
+
+// ValidateAddWithClock validates that a mutation can be added to the state like ValidateAdd,
+// and additionally rejects mutations timestamped more than MaxClockDrift ahead of the clock.
+func ValidateAddWithClock(clock Clock, state State, sm SignedMutation) error {
+	if sm.Mutation.Timestamp.After(clock.Now().Add(MaxClockDrift)) {
+		return fmt.Errorf("mutation timestamp in the future")
+	}
+
+	return ValidateAdd(state, sm)
+}
+
+// containsAll returns true if the cluster contains all the hashes.
+func containsAll(cluster Cluster, hashes []Hash) bool {
+	for _, h := range hashes {
+		if _, ok := cluster.Hashes[h]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
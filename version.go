@@ -0,0 +1,168 @@
+package clusterstate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version represents the semantic version of a mutation type.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Less returns true if v is an older version than o.
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	} else if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+
+	return v.Patch < o.Patch
+}
+
+// ParseVersion parses a "major.minor.patch" semantic version.
+func ParseVersion(s string) (Version, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version: %s", s)
+	}
+
+	var nums [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || strconv.Itoa(n) != part {
+			return Version{}, fmt.Errorf("invalid version: %s", s)
+		}
+		nums[i] = n
+	}
+
+	return Version{Major: nums[0], Minor: nums[1], Patch: nums[2]}, nil
+}
+
+// parseType splits the mutation type into its name and version,
+// e.g. "charon/add_validators/1.0.0" into "charon/add_validators" and 1.0.0.
+func parseType(t MutationType) (string, Version, error) {
+	i := strings.LastIndex(string(t), "/")
+	if i < 0 {
+		return "", Version{}, fmt.Errorf("invalid mutation type: %s", t)
+	}
+
+	name := string(t[:i])
+	if parts := strings.Split(name, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", Version{}, fmt.Errorf("invalid mutation type: %s", t)
+	}
+
+	version, err := ParseVersion(string(t[i+1:]))
+	if err != nil {
+		return "", Version{}, fmt.Errorf("invalid mutation type: %s: %w", t, err)
+	}
+
+	return name, version, nil
+}
+
+// Name returns the mutation type excluding the version, e.g. "charon/add_validators".
+// It returns the full type if it is invalid.
+func (t MutationType) Name() string {
+	name, _, err := parseType(t)
+	if err != nil {
+		return string(t)
+	}
+
+	return name
+}
+
+// Version returns the version of the mutation type.
+func (t MutationType) Version() (Version, error) {
+	_, version, err := parseType(t)
+	return version, err
+}
+
+// Latest returns the latest registered version of the mutation type.
+func (t MutationType) Latest() MutationType {
+	resp := t
+	name := t.Name()
+	latest, _ := t.Version()
+	for other := range typeDef {
+		if other == t || !strings.HasPrefix(string(other), name+"/") || other.Name() != name {
+			continue
+		}
+
+		version, err := other.Version()
+		if err != nil || !latest.Less(version) {
+			continue
+		}
+
+		resp, latest = other, version
+	}
+
+	return resp
+}
+
+// upgradeTarget returns the version that mutations of the type are upgraded to before being applied.
+// It is the type itself if it has no upgrade func, otherwise the latest version, since registration
+// requires every version older than the latest to have an upgrade func.
+func (t MutationType) upgradeTarget() MutationType {
+	if def, ok := typeDef[t]; !ok || def.UpgradeFunc == nil {
+		return t
+	}
+
+	return t.Latest()
+}
+
+// AllowsParent returns true if the mutation type may build on any version of the parent type.
+func (t MutationType) AllowsParent(parent MutationType) bool {
+	for p := range t.ParentTypes() {
+		if p.Name() == parent.Name() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// upgrade returns the mutation with its data translated to the latest version of its type
+// by applying the chain of registered upgrade functions.
+// The hash, source and signature of the original mutation are retained.
+// It returns an error if the upgraded version isn't the type's upgrade target,
+// since the target's approval policy and parent types apply to the mutation.
+func upgrade(sm SignedMutation) (SignedMutation, error) {
+	typ := sm.Mutation.Type
+	target := typ.upgradeTarget()
+	for {
+		def, ok := typeDef[sm.Mutation.Type]
+		if !ok {
+			return SignedMutation{}, fmt.Errorf("unknown mutation type: %s", sm.Mutation.Type)
+		} else if def.UpgradeFunc == nil && sm.Mutation.Type != target {
+			return SignedMutation{}, fmt.Errorf("upgrade %s: reached %s instead of %s", typ, sm.Mutation.Type, target)
+		} else if def.UpgradeFunc == nil {
+			return sm, nil
+		}
+
+		next, err := def.UpgradeFunc(sm.Mutation)
+		if err != nil {
+			return SignedMutation{}, fmt.Errorf("upgrade %s: %w", sm.Mutation.Type, err)
+		}
+
+		prev, err := sm.Mutation.Type.Version()
+		if err != nil {
+			return SignedMutation{}, err
+		}
+
+		version, err := next.Type.Version()
+		if err != nil {
+			return SignedMutation{}, err
+		} else if next.Type.Name() != sm.Mutation.Type.Name() || !prev.Less(version) {
+			return SignedMutation{}, fmt.Errorf("upgrade %s: invalid upgraded type %s", sm.Mutation.Type, next.Type)
+		}
+
+		sm.Mutation = next
+	}
+}
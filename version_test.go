@@ -0,0 +1,209 @@
+package clusterstate
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in  string
+		out Version
+		err bool
+	}{
+		{in: "1.0.0", out: Version{Major: 1}},
+		{in: "1.22.333", out: Version{Major: 1, Minor: 22, Patch: 333}},
+		{in: "1.0", err: true},
+		{in: "1.0.0.0", err: true},
+		{in: "01.0.0", err: true},
+		{in: "-1.0.0", err: true},
+		{in: "a.b.c", err: true},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			v, err := ParseVersion(test.in)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			} else if err != nil {
+				t.Fatal(err)
+			} else if v != test.out {
+				t.Fatalf("expected %v, got %v", test.out, v)
+			} else if v.String() != test.in {
+				t.Fatalf("expected %s, got %s", test.in, v)
+			}
+		})
+	}
+
+	if !(Version{Major: 1, Minor: 2}).Less(Version{Major: 1, Minor: 10}) {
+		t.Fatal("expected 1.2.0 < 1.10.0")
+	}
+}
+
+func TestMutationTypeName(t *testing.T) {
+	if name := TypeAddValidators.Name(); name != "charon/add_validators" {
+		t.Fatalf("unexpected name: %s", name)
+	}
+
+	v, err := TypeAddValidators.Version()
+	if err != nil {
+		t.Fatal(err)
+	} else if v != (Version{Major: 1}) {
+		t.Fatalf("unexpected version: %v", v)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	const (
+		typV1 MutationType = "test/metadata/1.0.0"
+		typV2 MutationType = "test/metadata/2.0.0"
+	)
+	type metadataV1 struct{ Label string }
+	type metadataV2 struct{ Name string }
+
+	defs := []struct {
+		typ MutationType
+		def TypeDef
+	}{
+		{typV2, TypeDef{
+			Approvals:   ApprovalsAll,
+			DataType:    metadataV2{},
+			ParentTypes: []MutationType{TypeCreateCluster},
+			ChildTypes:  []MutationType{TypeOperatorAck},
+			AppendFunc: func(m SignedMutation, c Cluster) (Cluster, error) {
+				data, ok := m.Mutation.Data.(metadataV2)
+				if !ok {
+					return Cluster{}, fmt.Errorf("invalid data type")
+				}
+				c.Name = data.Name
+
+				return c, nil
+			},
+		}},
+		{typV1, TypeDef{
+			DataType:    metadataV1{},
+			ParentTypes: []MutationType{TypeCreateCluster},
+			AppendFunc: func(SignedMutation, Cluster) (Cluster, error) {
+				return Cluster{}, fmt.Errorf("not upgraded")
+			},
+			UpgradeFunc: func(m Mutation) (Mutation, error) {
+				data, ok := m.Data.(metadataV1)
+				if !ok {
+					return Mutation{}, fmt.Errorf("invalid data type")
+				}
+				m.Type = typV2
+				m.Data = metadataV2{Name: data.Label}
+
+				return m, nil
+			},
+		}},
+	}
+	for _, d := range defs {
+		if err := RegisterMutationType(d.typ, d.def); err != nil {
+			t.Fatal(err)
+		}
+		typ := d.typ
+		t.Cleanup(func() { delete(typeDef, typ) })
+	}
+
+	if latest := typV1.Latest(); latest != typV2 {
+		t.Fatalf("unexpected latest: %s", latest)
+	}
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(2)
+	create := NewSignedMutation(clock, ops[0], TypeCreateCluster, newCreateCluster(ops))
+	metadata := NewSignedMutation(clock, ops[0], typV1, metadataV1{Label: "upgraded"}, create)
+	state := State{create, metadata}
+
+	// The v2 approval policy applies to the v1 mutation.
+	if !typV1.AllowsParent(TypeCreateCluster) || !TypeOperatorAck.AllowsParent(typV1) {
+		t.Fatal("expected parents allowed")
+	}
+
+	clusters, err := Resolve(state)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(clusters) != 1 || clusters[0].Name != "test-cluster" {
+		t.Fatalf("expected unapproved metadata, got %v", clusters)
+	}
+
+	for _, op := range ops {
+		state = append(state, NewSignedMutation(clock, op, TypeOperatorAck, OperatorAck{}, metadata))
+	}
+
+	clusters, err = Resolve(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cluster := range clusters {
+		if cluster.Name != "upgraded" {
+			t.Fatalf("expected upgraded metadata applied, got %s", cluster.Name)
+		} else if _, ok := cluster.Hashes[metadata.Hash]; !ok {
+			t.Fatal("expected original hash retained")
+		}
+	}
+}
+
+func TestUpgradeTarget(t *testing.T) {
+	const (
+		typV1 MutationType = "test/legacy/1.0.0"
+		typV2 MutationType = "test/legacy/2.0.0"
+		typV3 MutationType = "test/legacy/3.0.0"
+	)
+	appendFunc := func(_ SignedMutation, c Cluster) (Cluster, error) {
+		c.Name = "legacy"
+		return c, nil
+	}
+
+	// Inserted directly since registration rejects versions without an upgrade path to the latest.
+	defs := map[MutationType]TypeDef{
+		typV1: {Approvals: ApprovalsNone, ParentTypes: []MutationType{TypeCreateCluster}, AppendFunc: appendFunc},
+		typV2: {Approvals: ApprovalsAll, ParentTypes: []MutationType{TypeOperatorAck}, AppendFunc: appendFunc},
+	}
+	for typ, def := range defs {
+		typeDef[typ] = def
+		typ := typ
+		t.Cleanup(func() { delete(typeDef, typ) })
+	}
+
+	// The v1 mutation is applied without upgrading, so its own policy and parents apply, not v2's.
+	if !isNoApprovals(typV1.Approvals()) || isNoApprovals(typV2.Approvals()) {
+		t.Fatal("expected v1 without approvals and v2 with approvals")
+	} else if !typV1.AllowsParent(TypeCreateCluster) || typV1.AllowsParent(TypeOperatorAck) {
+		t.Fatal("expected v1 parents")
+	}
+
+	clock := NewFakeClock(time.Unix(0, 0))
+	ops := newOperators(2)
+	create := NewSignedMutation(clock, ops[0], TypeCreateCluster, newCreateCluster(ops))
+	legacy := NewSignedMutation(clock, ops[0], typV1, nil, create)
+	if err := ValidateAdd(State{create}, legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	clusters, err := Resolve(State{create, legacy})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(clusters) != 1 || clusters[0].Name != "legacy" {
+		t.Fatalf("expected legacy mutation applied without approvals, got %v", clusters)
+	}
+
+	// A v1 upgrade func that stops at v2 while v3 is the latest doesn't reach the version whose policy applies.
+	def := defs[typV1]
+	def.UpgradeFunc = func(m Mutation) (Mutation, error) {
+		m.Type = typV2
+		return m, nil
+	}
+	typeDef[typV1] = def
+	typeDef[typV3] = TypeDef{Approvals: ApprovalsAll, AppendFunc: appendFunc}
+	t.Cleanup(func() { delete(typeDef, typV3) })
+
+	if _, err := upgrade(legacy); err == nil || !strings.Contains(err.Error(), "instead of "+string(typV3)) {
+		t.Fatalf("expected upgrade target error, got %v", err)
+	}
+}